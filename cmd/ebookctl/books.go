@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+func bookCommand(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "list":
+		return listBooks(ctx, e, args[1:])
+	case "unpublish":
+		if len(args) != 2 {
+			return errUsage
+		}
+		id, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("book id: %w", err)
+		}
+		book, err := e.bookRepo.GetByID(ctx, uint(id))
+		if err != nil {
+			return err
+		}
+		if err := e.books.UnpublishBook(ctx, book.ID); err != nil {
+			return err
+		}
+		fmt.Printf("unpublished book %d %q\n", book.ID, book.Title)
+		return nil
+	default:
+		return errUsage
+	}
+}
+
+// listBooks reads the repositories rather than the cached service views, so
+// the output reflects changes made a moment ago.
+func listBooks(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("book list", flag.ContinueOnError)
+	author := fs.Uint("author", 0, "only books by this author id, including co-authored ones")
+	language := fs.String("language", "", "only books in this language")
+	limit := fs.Uint("limit", 20, "books per page")
+	page := fs.Uint("page", 1, "page number")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+	if *author != 0 && *language != "" {
+		return errors.New("book list: -author and -language cannot be combined")
+	}
+
+	p := &models.Pagination{Limit: *limit, Page: *page, Sort: "id desc"}
+	var err error
+	if *author != 0 {
+		p, err = e.bookRepo.GetByAuthor(ctx, *author, p)
+	} else {
+		var filter *models.BookFilter
+		if *language != "" {
+			filter = &models.BookFilter{Language: *language}
+		}
+		p, err = e.bookRepo.GetAll(ctx, p, filter)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println("no books")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAUTHOR\tLANGUAGE\tWORDS\tUPDATED\tTITLE")
+	for _, b := range p.Rows.([]models.Book) {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%s\t%s\n", b.ID, b.AuthorID, b.Language, b.WordCount, b.UpdatedAt.Local().Format(time.DateTime), b.Title)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("page %d of %d, %d books\n", p.Page, p.TotalPages, p.TotalRows)
+	return nil
+}
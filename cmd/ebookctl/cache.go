@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanBatch keeps each SCAN and UNLINK short, so flushing a large namespace
+// does not block Redis for other clients.
+const scanBatch = 500
+
+// flushable lists the namespaces that only hold derived read caches. Others
+// share the Redis instance with durable state, such as job records and the
+// idempotency results of writes, and must not be flushed.
+var flushable = []string{
+	"book", "books", "book_pages",
+	"author", "authors", "author_books",
+	"user", "users",
+	"work", "notifications",
+}
+
+// cacheCommand deletes every key in the given namespaces. A namespace is
+// the part of the key before the first colon, as used in the cache metrics:
+// "book" covers book:42, "books" covers the cached listings.
+func cacheCommand(ctx context.Context, e *env, args []string) error {
+	if len(args) < 2 || args[0] != "flush" {
+		return errUsage
+	}
+	for _, ns := range args[1:] {
+		if !slices.Contains(flushable, ns) {
+			return fmt.Errorf("namespace %q cannot be flushed; use one of %s", ns, strings.Join(flushable, ", "))
+		}
+	}
+	for _, ns := range args[1:] {
+		n, err := flushNamespace(ctx, e.redis, ns)
+		if err != nil {
+			return fmt.Errorf("flush %s: %w", ns, err)
+		}
+		fmt.Printf("flushed %d keys from %s\n", n, ns)
+	}
+	return nil
+}
+
+func flushNamespace(ctx context.Context, client *redis.Client, ns string) (int, error) {
+	var (
+		cursor  uint64
+		deleted int
+	)
+	for {
+		keys, next, err := client.Scan(ctx, cursor, ns+":*", scanBatch).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += int(n)
+		}
+		if next == 0 {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
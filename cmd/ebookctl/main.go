@@ -0,0 +1,158 @@
+// Command ebookctl operates an eBookReader deployment: it manages users and
+// books, flushes caches, runs migrations and seeds demo data. It reads the
+// same config.yaml and .env as the server.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Quavke/eBookReader/pkg/config"
+	"github.com/Quavke/eBookReader/pkg/logging"
+	"github.com/Quavke/eBookReader/pkg/migrations"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const usage = `usage: ebookctl <command> [arguments]
+
+commands:
+  user create <username>            create a user; the password is read from stdin
+  user delete <user>                delete a user and their author profile
+  user promote <user>               grant admin rights
+  user demote <user>                revoke admin rights
+  user reset-password <user>        set a new password read from stdin
+  book list [flags]                 list books, newest first
+  book unpublish <id>               remove a book regardless of its author
+  cache flush <namespace>...        delete read caches such as book or books
+  migrate up|down|status|create     manage the schema, see "ebookctl migrate"
+  seed [dataset|list]               load fixtures, the embedded "demo" set by
+                                    default, or a .yaml/.json file
+
+<user> is a numeric id or a username.`
+
+var errUsage = errors.New(usage)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, errUsage) || errors.Is(err, migrations.ErrUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	if args[0] == "migrate" {
+		return migrations.Command{Dir: migrations.SourceDir, Out: os.Stdout, Open: openSQL}.Run(ctx, args[1:])
+	}
+
+	var cmd func(ctx context.Context, e *env, args []string) error
+	switch args[0] {
+	case "user":
+		cmd = userCommand
+	case "book":
+		cmd = bookCommand
+	case "cache":
+		cmd = cacheCommand
+	case "seed":
+		cmd = seedCommand
+	default:
+		return errUsage
+	}
+	e, err := newEnv()
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+	return cmd(ctx, e, args[1:])
+}
+
+// env holds the connections and the services built on them, wired the same
+// way as in the server.
+type env struct {
+	db         *gorm.DB
+	redis      *redis.Client
+	userRepo   repositories.UserRepo
+	bookRepo   repositories.BookRepo
+	authorRepo repositories.AuthorRepo
+	users      services.UserService
+	authors    services.AuthorService
+	books      services.BookService
+}
+
+func newEnv() (*env, error) {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Log.Level == "" {
+		cfg.Log.Level = "warn"
+	}
+	logger := logging.New(os.Stderr, cfg.IsProd, cfg.Log.Level)
+	slog.SetDefault(logger)
+
+	db, err := config.NewDB(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	client, err := config.NewRedis(cfg)
+	if err != nil {
+		return nil, err
+	}
+	store, err := config.NewBlobStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepo := repositories.NewGormUserRepo(db)
+	bookRepo := repositories.NewGormBookRepo(db)
+	authorRepo := repositories.NewGormAuthorRepo(db)
+	contributorRepo := repositories.NewGormContributorRepo(db)
+	followRepo := repositories.NewGormFollowRepo(db)
+	return &env{
+		db:         db,
+		redis:      client,
+		userRepo:   userRepo,
+		bookRepo:   bookRepo,
+		authorRepo: authorRepo,
+		users:      services.NewUserService(userRepo, client),
+		authors:    services.NewAuthorService(authorRepo, bookRepo, followRepo, client, store),
+		books:      services.NewBookService(bookRepo, contributorRepo, client, cfg.Reader.WordsPerMinute, store),
+	}, nil
+}
+
+func (e *env) Close() {
+	e.redis.Close()
+	if sqlDB, err := e.db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+func openSQL() (*sql.DB, error) {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := config.NewDB(cfg, logging.New(os.Stderr, cfg.IsProd, cfg.Log.Level))
+	if err != nil {
+		return nil, err
+	}
+	return db.DB()
+}
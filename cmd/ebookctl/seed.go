@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Quavke/eBookReader/pkg/fixtures"
+)
+
+// seedCommand loads a fixtures dataset, "demo" unless another embedded name
+// or a YAML or JSON file is given. Running it again only fills in what is
+// missing.
+func seedCommand(ctx context.Context, e *env, args []string) error {
+	name := "demo"
+	switch len(args) {
+	case 0:
+	case 1:
+		name = args[0]
+	default:
+		return errUsage
+	}
+	if name == "list" {
+		fmt.Println(strings.Join(fixtures.Names(), "\n"))
+		return nil
+	}
+
+	dataset, err := fixtures.Open(name)
+	if err != nil {
+		return err
+	}
+	loader := fixtures.NewLoader(e.users, e.userRepo, e.authors, e.authorRepo, e.books, e.bookRepo)
+	res, err := loader.Load(ctx, dataset)
+	fmt.Printf("seeded %d users, %d authors, %d books\n", res.Users, res.Authors, res.Books)
+	return err
+}
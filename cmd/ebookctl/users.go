@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+// Same limits as models.RegisterReq.
+const (
+	minUsername = 5
+	minPassword = 8
+)
+
+func userCommand(ctx context.Context, e *env, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	action, ref := args[0], args[1]
+	if action == "create" {
+		if len(ref) < minUsername {
+			return fmt.Errorf("username must be at least %d characters", minUsername)
+		}
+		pwd, err := readPassword(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if err := e.users.CreateUser(ctx, ref, pwd); err != nil {
+			return err
+		}
+		user, err := e.userRepo.GetByUsername(ctx, ref)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user %d %s\n", user.ID, user.Username)
+		return nil
+	}
+
+	user, err := findUser(ctx, e, ref)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "delete":
+		err = e.users.DeleteUser(ctx, user.ID)
+	case "promote":
+		err = e.users.SetAdmin(ctx, user.ID, true)
+	case "demote":
+		err = e.users.SetAdmin(ctx, user.ID, false)
+	case "reset-password":
+		var pwd []byte
+		if pwd, err = readPassword(os.Stdin); err == nil {
+			err = e.users.ResetPassword(ctx, user.ID, pwd)
+		}
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: done for user %d %s\n", action, user.ID, user.Username)
+	return nil
+}
+
+// findUser accepts an id or a username.
+func findUser(ctx context.Context, e *env, ref string) (*models.UserDB, error) {
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return e.userRepo.GetByID(ctx, uint(id))
+	}
+	return e.userRepo.GetByUsername(ctx, ref)
+}
+
+// readPassword takes the first line of r, so it works both interactively
+// and with a pipe: echo "$PASSWORD" | ebookctl user create alice.
+func readPassword(r io.Reader) ([]byte, error) {
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprint(os.Stderr, "Password: ")
+		}
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	pwd := strings.TrimRight(line, "\r\n")
+	if len(pwd) < minPassword {
+		return nil, fmt.Errorf("password must be at least %d characters", minPassword)
+	}
+	return []byte(pwd), nil
+}
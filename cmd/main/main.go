@@ -1,12 +1,26 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/Quavke/eBookReader/pkg/config"
+	"github.com/Quavke/eBookReader/pkg/logging"
+	"github.com/Quavke/eBookReader/pkg/migrations"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
 	cfg, err := config.NewConfig()
 	if err != nil {
 		log.Fatalf("Config error: %s", err)
@@ -16,7 +30,37 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create app: %s", err)
 	}
-	if err := app.Run(); err != nil {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := app.Run(ctx); err != nil {
 		log.Fatal(err)
 	}
-}
\ No newline at end of file
+}
+
+func migrate(args []string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cmd := migrations.Command{
+		Dir: migrations.SourceDir,
+		Out: os.Stdout,
+		Open: func() (*sql.DB, error) {
+			cfg, err := config.NewConfig()
+			if err != nil {
+				return nil, err
+			}
+			db, err := config.NewDB(cfg, logging.New(os.Stderr, cfg.IsProd, cfg.Log.Level))
+			if err != nil {
+				return nil, err
+			}
+			return db.DB()
+		},
+	}
+	if err := cmd.Run(ctx, args); err != nil {
+		if errors.Is(err, migrations.ErrUsage) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		log.Fatalf("Migrate error: %s", err)
+	}
+}
@@ -2,29 +2,63 @@ package config
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/Quavke/eBookReader/pkg/controllers"
+	"github.com/Quavke/eBookReader/pkg/events"
+	"github.com/Quavke/eBookReader/pkg/fixtures"
+	"github.com/Quavke/eBookReader/pkg/health"
+	"github.com/Quavke/eBookReader/pkg/jobs"
+	"github.com/Quavke/eBookReader/pkg/logging"
+	"github.com/Quavke/eBookReader/pkg/metrics"
 	"github.com/Quavke/eBookReader/pkg/middlewares"
+	"github.com/Quavke/eBookReader/pkg/migrations"
 	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/outbox"
 	"github.com/Quavke/eBookReader/pkg/repositories"
 	"github.com/Quavke/eBookReader/pkg/routers"
 	"github.com/Quavke/eBookReader/pkg/services"
+	"github.com/Quavke/eBookReader/pkg/storage"
+	"github.com/Quavke/eBookReader/pkg/webhooks"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 type Config struct {
 		IsProd bool 					`mapstructure:"IS_PROD"`
+		Log struct {
+			Level string 				`mapstructure:"LEVEL"`
+		}											`mapstructure:"log"`
+		Tracing struct {
+			Exporter    string  `mapstructure:"EXPORTER"`
+			Endpoint    string  `mapstructure:"ENDPOINT"`
+			Insecure    bool    `mapstructure:"INSECURE"`
+			ServiceName string  `mapstructure:"SERVICE_NAME"`
+			SampleRatio float64 `mapstructure:"SAMPLE_RATIO"`
+		}											`mapstructure:"tracing"`
 		Server struct {
 			Port int      			`mapstructure:"PORT"`
+			ReadHeaderTimeout time.Duration `mapstructure:"READ_HEADER_TIMEOUT"`
+			ReadTimeout       time.Duration `mapstructure:"READ_TIMEOUT"`
+			WriteTimeout      time.Duration `mapstructure:"WRITE_TIMEOUT"`
+			IdleTimeout       time.Duration `mapstructure:"IDLE_TIMEOUT"`
+			ShutdownTimeout   time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+			DrainDelay        time.Duration `mapstructure:"DRAIN_DELAY"`
 		}											`mapstructure:"server"`
 		DB struct {
 			Host     	 string   `mapstructure:"HOST"`
@@ -33,16 +67,79 @@ type Config struct {
 			User     	 string   `mapstructure:"USER"`
 			TimeZone   string   `mapstructure:"TIME_ZONE"`
 			SSLMode    string   `mapstructure:"SSL_MODE"`
+			// MigrateOnStart applies pending migrations at boot, under the
+			// same lock as "migrate up". Off by default so schema changes
+			// are a deliberate release step.
+			MigrateOnStart bool `mapstructure:"MIGRATE_ON_START"`
 			DSN        string
 		}   									`mapstructure:"db"`
 		Redis struct {
 			Host string 				`mapstructure:"HOST"`
 			Port int 						`mapstructure:"PORT"`
-		}											`mapstructure:"redis"`		
+		}											`mapstructure:"redis"`
+		// Timeouts cap a single database statement or Redis command. Requests
+		// carry their own context too, so a client that goes away cancels
+		// the work earlier.
+		Timeouts struct {
+			DB    time.Duration `mapstructure:"DB"`
+			Redis time.Duration `mapstructure:"REDIS"`
+		}											`mapstructure:"timeouts"`		
+		Reader struct {
+			PageSize uint 			`mapstructure:"PAGE_SIZE"`
+			PageUnit string 		`mapstructure:"PAGE_UNIT"`
+			// MinPageSize and MaxPageSize bound the size a client may ask for.
+			MinPageSize uint 		`mapstructure:"MIN_PAGE_SIZE"`
+			MaxPageSize uint 		`mapstructure:"MAX_PAGE_SIZE"`
+			WordsPerMinute uint `mapstructure:"WORDS_PER_MINUTE"`
+		}											`mapstructure:"reader"`
+		Storage struct {
+			Driver    string   `mapstructure:"DRIVER"`
+			LocalDir  string   `mapstructure:"LOCAL_DIR"`
+			PublicURL string   `mapstructure:"PUBLIC_URL"`
+			MaxCoverSize int64 `mapstructure:"MAX_COVER_SIZE"`
+			MaxAvatarSize int64 `mapstructure:"MAX_AVATAR_SIZE"`
+			S3 struct {
+				Endpoint string   `mapstructure:"ENDPOINT"`
+				Bucket   string   `mapstructure:"BUCKET"`
+				Region   string   `mapstructure:"REGION"`
+				PublicURL string  `mapstructure:"PUBLIC_URL"`
+			}                   `mapstructure:"s3"`
+		}											`mapstructure:"storage"`
+		Jobs struct {
+			Workers           int           `mapstructure:"WORKERS"`
+			VisibilityTimeout time.Duration `mapstructure:"VISIBILITY_TIMEOUT"`
+			MaxImportSize     int64         `mapstructure:"MAX_IMPORT_SIZE"`
+		}											`mapstructure:"jobs"`
+		// Fixtures names a dataset loaded at boot, an embedded one such as
+		// "demo" or a path to a YAML or JSON file. Development only; in
+		// production run "ebookctl seed" on purpose instead.
+		Fixtures struct {
+			Dataset string `mapstructure:"DATASET"`
+		}											`mapstructure:"fixtures"`
 }
+// App owns the HTTP server and every long-lived resource behind it, and
+// releases them in order on shutdown.
 type App struct {
-	router *gin.Engine
-	cfg    *Config
+	router  *gin.Engine
+	cfg     *Config
+	server  *http.Server
+	db      *gorm.DB
+	redis   *redis.Client
+	workers *jobs.Pool
+	relay   *outbox.Relay
+	health  *health.Checker
+	// background is cancelled once HTTP traffic has drained, stopping the
+	// relay, the workers and the event broker.
+	background context.Context
+	stopBackground context.CancelFunc
+	// streams is cancelled as soon as shutdown starts: SSE and WebSocket
+	// connections never go idle, so the server would wait for them until
+	// the deadline.
+	streams context.Context
+	closeStreams context.CancelFunc
+	// wg tracks the relay, the workers and webhook deliveries.
+	wg *sync.WaitGroup
+	shutdownTracing func(context.Context) error
 }
 
 func NewConfig() (*Config, error) {
@@ -74,78 +171,449 @@ func NewConfig() (*Config, error) {
 	return &cfg, nil
 }
 
-
-func NewApp(cfg *Config) (*App, error) {
-	router := gin.Default()
-	router.Use(func(c *gin.Context) {
-		c.Set("isProd", cfg.IsProd)
-		c.Next()
-	})
-	err := godotenv.Load()
-	if err != nil{
+// NewDB connects to Postgres with the pool settings and statement timeouts
+// shared by the server and the command-line tools.
+func NewDB(cfg *Config, logger *slog.Logger) (*gorm.DB, error) {
+	if err := godotenv.Load(); err != nil {
 		return nil, err
 	}
-	v1 := router.Group("/api/v1")
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
 		cfg.DB.Host, cfg.DB.User, os.Getenv("DB_PASSWORD"), cfg.DB.Name, cfg.DB.Port, cfg.DB.SSLMode, cfg.DB.TimeZone,
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-  Logger: logger.Default.LogMode(logger.Info),
+		Logger: logging.GormLogger{Logger: logger, SlowThreshold: 200 * time.Millisecond},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(1 * time.Hour)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	if cfg.Timeouts.DB == 0 {
+		cfg.Timeouts.DB = 10 * time.Second
 	}
-	db.AutoMigrate(&models.Author{}, &models.Book{}, &models.UserDB{})
-
-	context := context.Background()
+	if err := db.Use(repositories.TimeoutPlugin{Timeout: cfg.Timeouts.DB}); err != nil {
+		return nil, fmt.Errorf("failed to register gorm timeouts: %v", err)
+	}
+	return db, nil
+}
 
-	addr := fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)
+// NewRedis returns a client bounded by the configured command timeout. It
+// does not connect until first use.
+func NewRedis(cfg *Config) (*redis.Client, error) {
 	if cfg.Redis.Host == "" || cfg.Redis.Port == 0 {
 		return nil, fmt.Errorf("redis host/port are required")
 	}
-
-	client := redis.NewClient(&redis.Options{
-        Addr:	  addr,
+	if cfg.Timeouts.Redis == 0 {
+		cfg.Timeouts.Redis = 5 * time.Second
+	}
+	return redis.NewClient(&redis.Options{
+        Addr:	  fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
         Password: os.Getenv("REDIS_PASSWORD"),
         DB:		  0,
         Protocol: 2,
-				ReadTimeout: 5 * time.Second,
-				WriteTimeout: 5 * time.Second,
-  })
+				ReadTimeout: cfg.Timeouts.Redis,
+				WriteTimeout: cfg.Timeouts.Redis,
+				ContextTimeoutEnabled: true,
+  }), nil
+}
+
+func NewApp(cfg *Config) (*App, error) {
+	logger := logging.New(os.Stdout, cfg.IsProd, cfg.Log.Level)
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up tracing: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(otelgin.Middleware(serviceName(cfg), otelgin.WithFilter(func(r *http.Request) bool {
+		// Probes and scrapes would drown real traffic.
+		return r.URL.Path != "/healthz" && r.URL.Path != "/readyz" && r.URL.Path != "/metrics"
+	})))
+	router.Use(middlewares.RequestIDMiddleware())
+	router.Use(middlewares.AccessLogMiddleware(logger))
+	router.Use(middlewares.MetricsMiddleware())
+	router.Use(func(c *gin.Context) {
+		c.Set("isProd", cfg.IsProd)
+		c.Next()
+	})
+	v1 := router.Group("/api/v1")
+	db, err := NewDB(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+	if err := db.Use(gormtracing.NewPlugin(gormtracing.WithoutQueryVariables(), gormtracing.WithoutMetrics())); err != nil {
+		return nil, fmt.Errorf("failed to register gorm tracing: %v", err)
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register gorm metrics: %v", err)
+	}
+	if err := metrics.RegisterDB(sqlDB, cfg.DB.Name); err != nil {
+		return nil, fmt.Errorf("failed to register db pool metrics: %v", err)
+	}
+	schema, err := migrations.Embedded()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %v", err)
+	}
+	migrator := migrations.New(sqlDB, schema)
+	var migrateErr error
+	if cfg.DB.MigrateOnStart {
+		applied, err := migrator.Up(context.Background())
+		for _, m := range applied {
+			logger.Info("Applied migration", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			migrateErr = err
+			logger.Error("Migrations failed, the app will not report ready", "error", err)
+		}
+	}
+
+	client, err := NewRedis(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.AddHook(metrics.RedisHook{})
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		return nil, fmt.Errorf("failed to instrument redis tracing: %v", err)
+	}
 	
+	blobStore, err := NewBlobStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blob storage: %v", err)
+	}
+	if cfg.Storage.Driver == "" || cfg.Storage.Driver == "local" {
+		router.Static(cfg.Storage.PublicURL, cfg.Storage.LocalDir)
+	}
+
+	var dataset *fixtures.Dataset
+	if cfg.Fixtures.Dataset != "" {
+		if cfg.IsProd {
+			return nil, fmt.Errorf("fixtures.DATASET is set in production, use ebookctl seed instead")
+		}
+		if dataset, err = fixtures.Open(cfg.Fixtures.Dataset); err != nil {
+			return nil, fmt.Errorf("failed to open fixtures: %v", err)
+		}
+	}
+
+	background, stopBackground := context.WithCancel(context.Background())
+	wg := new(sync.WaitGroup)
+	streams, closeStreams := context.WithCancel(context.Background())
+
 	bookRepo := repositories.NewGormBookRepo(db)
-	bookService := services.NewBookService(bookRepo, context, client)
+	contributorRepo := repositories.NewGormContributorRepo(db)
+	followRepo := repositories.NewGormFollowRepo(db)
+	notificationRepo := repositories.NewGormNotificationRepo(db)
+	broker := events.NewRedisBroker(background, client)
+	eventService := services.NewEventService(broker, followRepo)
+	eventController := controllers.NewEventController(eventService)
+
+	notificationService := services.NewNotificationService(notificationRepo, followRepo, eventService, client)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	userRepo := repositories.NewGormUserRepo(db)
+	webhookRepo := repositories.NewGormWebhookRepo(db)
+	webhookService := services.NewWebhookService(webhookRepo, userRepo, webhooks.NewSender(nil), background, wg, blobStore)
+	webhookController := controllers.NewWebhookController(webhookService)
+
+	bookService := services.NewBookService(bookRepo, contributorRepo, client, cfg.Reader.WordsPerMinute, blobStore)
 	bookController := controllers.NewBookController(bookService)
 	
+	if cfg.Storage.MaxCoverSize == 0 {
+		cfg.Storage.MaxCoverSize = 5 << 20
+	}
+	if cfg.Storage.MaxAvatarSize == 0 {
+		cfg.Storage.MaxAvatarSize = 2 << 20
+	}
+
 	authorRepo := repositories.NewGormAuthorRepo(db)
-	authorService := services.NewAuthorService(authorRepo, context, client)
-	authorController := controllers.NewAuthorController(authorService)
+	authorService := services.NewAuthorService(authorRepo, bookRepo, followRepo, client, blobStore)
+	authorController := controllers.NewAuthorController(authorService, cfg.Storage.MaxAvatarSize)
 
-	userRepo := repositories.NewGormUserRepo(db)
-	userService := services.NewUserService(userRepo, context, client)
+	contributorService := services.NewContributorService(contributorRepo, bookRepo, authorRepo, client)
+	contributorController := controllers.NewContributorController(contributorService)
+
+	followService := services.NewFollowService(followRepo, authorRepo, client, blobStore)
+	followController := controllers.NewFollowController(followService)
+
+	workRepo := repositories.NewGormWorkRepo(db)
+	workService := services.NewWorkService(workRepo, bookRepo, client, blobStore)
+	workController := controllers.NewWorkController(workService)
+
+	coverService := services.NewCoverService(bookRepo, blobStore, client, cfg.Storage.MaxCoverSize)
+	coverController := controllers.NewCoverController(coverService, cfg.Storage.MaxCoverSize)
+
+	progressRepo := repositories.NewGormProgressRepo(db)
+	progressService := services.NewProgressService(progressRepo, bookRepo, eventService)
+	progressController := controllers.NewProgressController(progressService)
+
+	bus := outbox.NewLocalBus()
+	bus.Subscribe(outbox.AllTopics, "redis-stream", outbox.StreamHandler(client, "outbox:events"))
+	services.SubscribeBookEvents(bus, notificationService, eventService, webhookService, blobStore)
+	relay := outbox.NewRelay(repositories.NewGormOutboxRepo(db), bus, time.Second)
+
+	if cfg.Jobs.MaxImportSize == 0 {
+		cfg.Jobs.MaxImportSize = 50 << 20
+	}
+	var queue jobs.Queue = jobs.NewRedisQueue(client)
+	if err := client.Ping(background).Err(); err != nil {
+		logger.Warn("Redis is unavailable, jobs are kept in memory", "error", err)
+		queue = jobs.NewMemoryQueue()
+	}
+	jobService := services.NewJobService(queue)
+	jobController := controllers.NewJobController(jobService)
+	transferService := services.NewTransferService(jobService, bookService, bookRepo, blobStore)
+	transferController := controllers.NewTransferController(transferService, cfg.Jobs.MaxImportSize)
+	workers := jobs.NewPool(queue, cfg.Jobs.Workers, cfg.Jobs.VisibilityTimeout)
+	workers.Register(models.JobBookImport, jobs.Typed(transferService.RunImport))
+	workers.Register(models.JobBookExport, jobs.Typed(transferService.RunExport))
+
+	paginatorService := services.NewPaginatorService(bookRepo, client, cfg.Reader.PageSize, cfg.Reader.PageUnit, cfg.Reader.MinPageSize, cfg.Reader.MaxPageSize)
+	pageController := controllers.NewPageController(paginatorService)
+
+	userService := services.NewUserService(userRepo, client)
 	userController := controllers.NewUserController(userService)
 
+	if dataset != nil {
+		loaded, err := fixtures.NewLoader(userService, userRepo, authorService, authorRepo, bookService, bookRepo).Load(background, dataset)
+		if err != nil {
+			stopBackground()
+			closeStreams()
+			return nil, fmt.Errorf("failed to load fixtures: %v", err)
+		}
+		logger.Info("Loaded fixtures", "dataset", cfg.Fixtures.Dataset, "users", loaded.Users, "authors", loaded.Authors, "books", loaded.Books)
+	}
+
 	AuthMiddleware := middlewares.AuthMiddleware(userRepo)
 	BooksMiddleware := middlewares.BooksMiddleware(userRepo)
+	StreamMiddleware := middlewares.StreamMiddleware(streams)
 
 	routers.RegisterBookRoutes(v1, bookController, AuthMiddleware, BooksMiddleware)
+	routers.RegisterPageRoutes(v1, pageController)
+	routers.RegisterCoverRoutes(v1, coverController, AuthMiddleware, BooksMiddleware)
+	routers.RegisterWorkRoutes(v1, workController, AuthMiddleware, BooksMiddleware)
+	routers.RegisterContributorRoutes(v1, contributorController, AuthMiddleware, BooksMiddleware)
 	routers.RegisterAuthorRoutes(v1, authorController, AuthMiddleware)
 	routers.RegisterUserRoutes(v1, userController, AuthMiddleware)
+	routers.RegisterFollowRoutes(v1, followController, AuthMiddleware)
+	routers.RegisterNotificationRoutes(v1, notificationController, AuthMiddleware)
+	routers.RegisterEventRoutes(v1, eventController, AuthMiddleware, StreamMiddleware)
+	routers.RegisterProgressRoutes(v1, progressController, AuthMiddleware, StreamMiddleware)
+	routers.RegisterWebhookRoutes(v1, webhookController, AuthMiddleware, BooksMiddleware)
+	routers.RegisterJobRoutes(v1, jobController, AuthMiddleware)
+	routers.RegisterTransferRoutes(v1, transferController, AuthMiddleware, BooksMiddleware)
+
+	checker := newHealthChecker(sqlDB, client, migrator, migrateErr, workers)
+	routers.RegisterHealthRoutes(&router.RouterGroup, controllers.NewHealthController(checker))
+	routers.RegisterMetricsRoutes(&router.RouterGroup)
+	if cfg.Server.ReadHeaderTimeout == 0 {
+		cfg.Server.ReadHeaderTimeout = 10 * time.Second
+	}
+	if cfg.Server.IdleTimeout == 0 {
+		cfg.Server.IdleTimeout = 2 * time.Minute
+	}
+	if cfg.Server.ShutdownTimeout == 0 {
+		cfg.Server.ShutdownTimeout = 30 * time.Second
+	}
+	if cfg.Server.DrainDelay == 0 {
+		cfg.Server.DrainDelay = 5 * time.Second
+	}
+	// ReadTimeout and WriteTimeout stay unlimited by default: they cover the
+	// whole request, so they would cut event streams and slow uploads.
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	server.RegisterOnShutdown(closeStreams)
 	return &App{
-		router: router,
-		cfg:    cfg,
+		router:  router,
+		cfg:     cfg,
+		server:  server,
+		db:      db,
+		redis:   client,
+		workers: workers,
+		relay:   relay,
+		health:  checker,
+		background: background,
+		stopBackground: stopBackground,
+		wg: wg,
+		streams: streams,
+		closeStreams: closeStreams,
+		shutdownTracing: shutdownTracing,
 	}, nil
 }
 
-func (a *App) Run() error {
-	return a.router.Run(fmt.Sprintf(":%d", a.cfg.Server.Port))
+func serviceName(cfg *Config) string {
+	if cfg.Tracing.ServiceName != "" {
+		return cfg.Tracing.ServiceName
+	}
+	return tracing.DefaultServiceName
+}
+
+func newHealthChecker(sqlDB *sql.DB, client *redis.Client, migrator *migrations.Migrator, migrateErr error, workers *jobs.Pool) *health.Checker {
+	checker := health.NewChecker()
+	checker.Register("postgres", sqlDB.PingContext)
+	checker.Register("redis", func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+	// The schema must be current before this build serves traffic; run
+	// "migrate up" first, or set DB.MIGRATE_ON_START.
+	checker.Register("migrations", func(ctx context.Context) error {
+		if migrateErr != nil {
+			return migrateErr
+		}
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations, first %04d_%s", len(pending), pending[0].Version, pending[0].Name)
+		}
+		return nil
+	})
+	checker.Register("workers", func(ctx context.Context) error {
+		return workers.Healthy()
+	})
+	return checker
+}
+
+// NewBlobStore builds the configured store. Files of the local driver are
+// served by the app itself under PublicURL.
+func NewBlobStore(cfg *Config) (storage.BlobStore, error) {
+	switch cfg.Storage.Driver {
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.Storage.S3.Endpoint,
+			Bucket:    cfg.Storage.S3.Bucket,
+			Region:    cfg.Storage.S3.Region,
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: cfg.Storage.S3.PublicURL,
+		}, nil)
+	case "", "local":
+		if cfg.Storage.LocalDir == "" {
+			cfg.Storage.LocalDir = "./data/blobs"
+		}
+		if cfg.Storage.PublicURL == "" {
+			cfg.Storage.PublicURL = "/media"
+		}
+		return storage.NewLocalStore(cfg.Storage.LocalDir, cfg.Storage.PublicURL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// Run starts the background loops and serves HTTP until ctx is cancelled or
+// the listener fails, then shuts down.
+func (a *App) Run(ctx context.Context) error {
+	a.wg.Add(2)
+	go func() {
+		defer a.wg.Done()
+		a.relay.Run(a.background)
+	}()
+	go func() {
+		defer a.wg.Done()
+		a.workers.Run(a.background)
+	}()
+
+	served := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", a.server.Addr)
+		served <- a.server.ListenAndServe()
+	}()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	case serveErr = <-served:
+	}
+	if errors.Is(serveErr, http.ErrServerClosed) {
+		serveErr = nil
+	}
+	return errors.Join(serveErr, a.Shutdown())
+}
+
+// abortGrace is how long Shutdown waits for cancelled jobs to return after
+// the deadline has passed.
+const abortGrace = 2 * time.Second
+
+// Shutdown first fails readiness and keeps serving for the drain delay, so
+// load balancers stop routing new requests here. It then stops accepting
+// requests and waits for in-flight ones, stops background work and closes
+// Redis and the database, all within the configured deadline; only jobs
+// cancelled at the deadline get abortGrace on top. Later steps run even if
+// an earlier one times out.
+func (a *App) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	a.health.SetDraining()
+	select {
+	case <-time.After(a.cfg.Server.DrainDelay):
+	case <-ctx.Done():
+	}
+
+	var errs []error
+	a.closeStreams()
+	if err := a.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("drain http connections: %w", err))
+	}
+
+	// Running jobs do not see stopBackground: they keep going up to the
+	// visibility timeout, which is usually longer than the shutdown
+	// deadline, and are only cancelled once the deadline is reached. They
+	// get abortGrace to report the failed attempt before Redis is closed;
+	// jobs that miss it run again after their visibility timeout.
+	a.stopBackground()
+	stopped := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.workers.Abort()
+		select {
+		case <-stopped:
+		case <-time.After(abortGrace):
+			errs = append(errs, errors.New("background workers did not stop before the shutdown deadline"))
+		}
+	}
+
+	if err := a.redis.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close redis: %w", err))
+	}
+	if sqlDB, err := a.db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close database: %w", err))
+		}
+	}
+	if err := a.shutdownTracing(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("flush traces: %w", err))
+	}
+	return errors.Join(errs...)
 }
\ No newline at end of file
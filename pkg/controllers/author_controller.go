@@ -1,22 +1,27 @@
 package controllers
 
 import (
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
 	"github.com/Quavke/eBookReader/pkg/services"
+	"github.com/Quavke/eBookReader/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AuthorController struct {
 	AuthorService services.AuthorService
+	maxAvatarSize int64
 }
 
-func NewAuthorController(service services.AuthorService) *AuthorController {
-	return &AuthorController{AuthorService: service}
+func NewAuthorController(service services.AuthorService, maxAvatarSize int64) *AuthorController {
+	return &AuthorController{AuthorService: service, maxAvatarSize: maxAvatarSize}
 }
 
 func (ctrl *AuthorController) GetAll(c *gin.Context){
@@ -26,22 +31,29 @@ func (ctrl *AuthorController) GetAll(c *gin.Context){
 	limit, err := strconv.ParseUint(limitStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer limit"})
-		log.Printf("Author controller GetAll error, cast limit to int. Error: %s", err.Error())
+		slog.WarnContext(c.Request.Context(), "Author controller GetAll error, cast limit to int", "error", err)
 		return
 	}
 
 	page, err := strconv.ParseUint(pageStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer page"})
-		log.Printf("Author controller GetAll error, cast page to int. Error: %s", err.Error())
+		slog.WarnContext(c.Request.Context(), "Author controller GetAll error, cast page to int", "error", err)
 		return
 	}
 
 	
-	authors, err := ctrl.AuthorService.GetAllAuthors(uint(limit), uint(page), "user_id desc")
+	authors, err := ctrl.AuthorService.GetAllAuthors(c.Request.Context(), uint(limit), uint(page), "user_id desc")
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get all authors"})
-    log.Printf("Author controller GetAll error, service method GetAllAuthors. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "Author controller GetAll error, service method GetAllAuthors", "error", err)
+		return
+	}
+	etag, err := utils.StrongETag(authors)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Author controller GetAll error, build ETag", "error", err)
+	}
+	if notModified(c, etag, paginationLastModified(authors)) {
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: authors})
@@ -52,13 +64,38 @@ func (ctrl *AuthorController) GetByID(c *gin.Context){
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
-    log.Printf("Author controller GetByID error, cast id to int. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "Author controller GetByID error, cast id to int", "error", err)
+		return
+	}
+	limit, err := strconv.ParseUint(c.DefaultQuery("l", "20"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer limit"})
+		slog.WarnContext(c.Request.Context(), "Author controller GetByID error, cast limit to int", "error", err)
 		return
 	}
-	author, err := ctrl.AuthorService.GetAuthorByID(uint(id))
+	page, err := strconv.ParseUint(c.DefaultQuery("p", "1"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer page"})
+		slog.WarnContext(c.Request.Context(), "Author controller GetByID error, cast page to int", "error", err)
+		return
+	}
+	author, err := ctrl.AuthorService.GetAuthorByID(c.Request.Context(), uint(id), uint(limit), uint(page))
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get author by this id"})
-    log.Printf("Author controller GetByID error, service method GetAuthorByID. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "Author controller GetByID error, service method GetAuthorByID", "error", err)
+		return
+	}
+	// The profile embeds a page of books, so the tag has to change with them
+	// while still carrying the author version for If-Match on PUT /authors/me.
+	etag, err := utils.CompositeETag(author.Version, author)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Author controller GetByID error, build ETag", "error", err)
+	}
+	lastModified := author.UpdatedAt
+	if booksModified := paginationLastModified(author.Books); booksModified.After(lastModified) {
+		lastModified = booksModified
+	}
+	if notModified(c, etag, lastModified) {
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: author})
@@ -71,13 +108,13 @@ func (ctrl *AuthorController) Create(c *gin.Context){
 
 	if err := c.ShouldBindBodyWithJSON(&author); err != nil {
     c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent Firstname, Lastname, Birthday(yyyy-mm-dd)"})
-    log.Printf("Author controller Create error, bind. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "Author controller Create error, bind", "error", err)
 		return
 	}
 	author.UserID = user.UserID
-	if err := ctrl.AuthorService.CreateAuthor(&author); err != nil {
+	if err := ctrl.AuthorService.CreateAuthor(c.Request.Context(), &author); err != nil {
     c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "cannot create author"})
-    log.Printf("Author controller Create error, service method CreateAuthor. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "Author controller Create error, service method CreateAuthor", "error", err)
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful create"})
@@ -88,23 +125,32 @@ func (ctrl *AuthorController) Update(c *gin.Context){
 	
 	claims := c.MustGet("claims").(*models.Claims)
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	if err := c.ShouldBindBodyWithJSON(&author); err != nil {
-    c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent Firstname or Lastname or Birthday(yyyy-mm-dd)"})
-    log.Printf("Author controller Update error, bind. Error: %s", err.Error())
+    c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent at least one of firstname, lastname, birthday(yyyy-mm-dd), bio, pen_names, website, links, country"})
+    slog.WarnContext(c.Request.Context(), "Author controller Update error, bind", "error", err)
 		return
 	}
 
-	if author.Firstname == "" && author.Lastname == "" && author.Birthday.IsZero() {
+	if author.IsEmpty() {
 		c.JSON(http.StatusOK, models.APIResponse[any]{
 				Message: "successful update", 
 				Data: "No fields to update"})
 		return
 	}
 
-	if err := ctrl.AuthorService.UpdateAuthor(&author, claims.UserID); err != nil {
+	if err := ctrl.AuthorService.UpdateAuthor(c.Request.Context(), &author, claims.UserID, version); err != nil {
+		if errors.Is(err, repositories.ErrVersionMismatch) {
+			c.JSON(http.StatusPreconditionFailed, models.APIResponse[any]{Message: "error", Error: "author was modified by someone else, reload it and try again"})
+			slog.WarnContext(c.Request.Context(), "Author controller Update error, version is stale", "version", version, "error", err)
+			return
+		}
     c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "cannot update author"})
-    log.Printf("Author controller Update error, service method UpdateAuthor. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "Author controller Update error, service method UpdateAuthor", "error", err)
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful update"})
@@ -113,32 +159,35 @@ func (ctrl *AuthorController) Update(c *gin.Context){
 func (ctrl *AuthorController) Delete(c *gin.Context){
 	claims := c.MustGet("claims").(*models.Claims)
 
-	if err := ctrl.AuthorService.DeleteAuthor(claims.UserID); err != nil {
+	if err := ctrl.AuthorService.DeleteAuthor(c.Request.Context(), claims.UserID); err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "can't delete author by this id"})
-    log.Printf("Author controller Delete error, service method DeleteAuthor. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "Author controller Delete error, service method DeleteAuthor", "error", err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
-func (ctrl *AuthorController) GetCreateMock(c *gin.Context) {
-	authors := make([]models.Author, 200)
-	for i := range authors {
-    authors[i] = models.Author{
-        UserID: uint(i+1),
-				Firstname: "firstname" + strconv.Itoa(i+1),
-				Lastname: "lastname" + strconv.Itoa(i+1),
-				Birthday: models.DateOnly{Time: models.DateOnly{}.Time.AddDate(0, 0, i+1),
-			},
-		}
+func (ctrl *AuthorController) UploadAvatar(c *gin.Context){
+	claims := c.MustGet("claims").(*models.Claims)
+
+	data, err := readUpload(c, "avatar", ctrl.maxAvatarSize)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Author controller UploadAvatar error, read upload", "error", err)
+		return
 	}
-	for i := range authors {
-		if err := ctrl.AuthorService.CreateAuthor(&authors[i]); err != nil {
-			log.Printf("User controller GetCreateMock error, service method CreateUser. Error: %s", err.Error())
-			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create mock users"})
-			return
+
+	avatar, err := ctrl.AuthorService.UploadAvatar(c.Request.Context(), claims.UserID, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrUnsupportedImage), errors.Is(err, utils.ErrImageTooLarge):
+			c.JSON(http.StatusUnsupportedMediaType, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "you need to create an author profile first"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot upload avatar"})
 		}
+		slog.ErrorContext(c.Request.Context(), "Author controller UploadAvatar error, service method UploadAvatar", "error", err)
+		return
 	}
-
-	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful create"})
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful upload", Data: avatar})
 }
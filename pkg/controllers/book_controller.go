@@ -1,12 +1,17 @@
 package controllers
 
 import (
-	"log"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
 	"github.com/Quavke/eBookReader/pkg/services"
+	"github.com/Quavke/eBookReader/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,21 +31,45 @@ func (ctrl *BookController) GetAll(c *gin.Context){
 	limit, err := strconv.ParseUint(limitStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer limit"})
-		log.Printf("Author controller GetAll error, cast limit to int. Error: %s", err.Error())
+		slog.WarnContext(c.Request.Context(), "Author controller GetAll error, cast limit to int", "error", err)
 		return
 	}
 
 	page, err := strconv.ParseUint(pageStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer page"})
-		log.Printf("Author controller GetAll error, cast page to int. Error: %s", err.Error())
+		slog.WarnContext(c.Request.Context(), "Author controller GetAll error, cast page to int", "error", err)
 		return
 	}
 
-	books, err := ctrl.BookService.GetAllBooks(uint(limit), uint(page), "id desc")
+	filter, err := parseBookFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "filters must be numbers: min_words, max_words, min_minutes, max_minutes, min_readability, max_readability"})
+		slog.ErrorContext(c.Request.Context(), "Book controller GetAll error, parse filters", "error", err)
+		return
+	}
+
+	books, err := ctrl.BookService.GetAllBooks(c.Request.Context(), uint(limit), uint(page), c.Query("sort"), filter)
+	if errors.Is(err, services.ErrInvalidLanguage) {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		slog.WarnContext(c.Request.Context(), "Book controller GetAll error, bad language", "language", c.Query("language"), "error", err)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidSort) {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "sort must be one of id, title, created, updated, words, reading_time, readability, optionally prefixed with -"})
+		slog.WarnContext(c.Request.Context(), "Book controller GetAll error, bad sort", "sort", c.Query("sort"), "error", err)
+		return
+	}
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get all books"})
-		log.Printf("Book controller GetAll error. Error: %s", err.Error())
+		slog.ErrorContext(c.Request.Context(), "Book controller GetAll error", "error", err)
+		return
+	}
+	etag, err := utils.StrongETag(books)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Book controller GetAll error, build ETag", "error", err)
+	}
+	if notModified(c, etag, paginationLastModified(books)) {
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: books})
@@ -51,18 +80,49 @@ func (ctrl *BookController) GetByID(c *gin.Context){
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
-    log.Printf("Book controller GetByID error, cast id to int. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "Book controller GetByID error, cast id to int", "error", err)
 		return
 	}
-	book, err := ctrl.BookService.GetBookByID(uint(id))
+	book, err := ctrl.BookService.GetBookByID(c.Request.Context(), uint(id))
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get book by this id"})
-    log.Printf("Book controller GetByID error. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "Book controller GetByID error", "error", err)
+		return
+	}
+	if notModified(c, utils.VersionETag(book.Version), book.UpdatedAt) {
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful create", Data: book})
 }
 
+func (ctrl *BookController) GetContent(c *gin.Context){
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+    c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+    slog.WarnContext(c.Request.Context(), "Book controller GetContent error, cast id to int", "error", err)
+		return
+	}
+	format := c.DefaultQuery("format", "txt")
+	content, err := ctrl.BookService.GetBookContent(c.Request.Context(), uint(id), format)
+	if errors.Is(err, services.ErrUnsupportedFormat) {
+    c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "unsupported format, use txt or html"})
+    slog.WarnContext(c.Request.Context(), "Book controller GetContent error, bad format", "format", format, "error", err)
+		return
+	}
+	if err != nil {
+    c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get book content by this id"})
+    slog.ErrorContext(c.Request.Context(), "Book controller GetContent error, service method GetBookContent", "error", err)
+		return
+	}
+
+	c.Header("Content-Type", content.ContentType)
+	c.Header("ETag", fmt.Sprintf(`"%d-%s"`, content.Version, content.Format))
+	c.Header("Cache-Control", publicCacheControl)
+	// ServeContent answers Range, multi-range and conditional requests for us.
+	http.ServeContent(c.Writer, c.Request, "", content.UpdatedAt, bytes.NewReader(content.Body))
+}
+
 func (ctrl *BookController) Create(c *gin.Context){
 	var book models.Book
 
@@ -70,15 +130,15 @@ func (ctrl *BookController) Create(c *gin.Context){
 
 	if err := c.ShouldBindJSON(&book); err != nil {
     c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent Title(min 3 chars), Content(min 50 chars)"})
-    log.Printf("Book controller Create error, bind. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "Book controller Create error, bind", "error", err)
 		return
 	}
 
 	book.AuthorID = claims.UserID
 
-	if err := ctrl.BookService.CreateBook(&book); err != nil {
+	if err := ctrl.BookService.CreateBook(c.Request.Context(), &book); err != nil {
     c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "cannot create book"})
-        log.Printf("Book controller Create error, service method CreateBook. Error: %s", err.Error())
+        slog.ErrorContext(c.Request.Context(), "Book controller Create error, service method CreateBook", "error", err)
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful create"})
@@ -93,14 +153,18 @@ func (ctrl *BookController) Update(c *gin.Context){
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot cast id to integer"})
-    log.Printf("Book controller Update error, cast id to int. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "Book controller Update error, cast id to int", "error", err)
 		return
 	}
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	if err := c.ShouldBindJSON(&book); err != nil {
     c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent Title(min 3 chars), Content(min 50 chars)"})
-    log.Printf("Book controller Update error, bind. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "Book controller Update error, bind", "error", err)
 		return
 	}
 
@@ -111,9 +175,14 @@ func (ctrl *BookController) Update(c *gin.Context){
 		return
 	}
 
-	if err := ctrl.BookService.UpdateBook(&book, uint(id), claims.UserID); err != nil {
+	if err := ctrl.BookService.UpdateBook(c.Request.Context(), &book, uint(id), claims.UserID, version); err != nil {
+		if errors.Is(err, repositories.ErrVersionMismatch) {
+			c.JSON(http.StatusPreconditionFailed, models.APIResponse[any]{Message: "error", Error: "book was modified by someone else, reload it and try again"})
+			slog.WarnContext(c.Request.Context(), "Book controller Update error, version is stale", "version", version, "error", err)
+			return
+		}
     c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "cannot update book"})
-    log.Printf("Book controller Update error, service method UpdateBook. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "Book controller Update error, service method UpdateBook", "error", err)
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful update"})
@@ -124,36 +193,50 @@ func (ctrl *BookController) Delete(c *gin.Context){
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot cast id to integer"})
-    log.Printf("Book controller Delete error, cast id to int. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "Book controller Delete error, cast id to int", "error", err)
 		return
 	}
 
 	claims := c.MustGet("claims").(*models.Claims)
 
-	if err := ctrl.BookService.DeleteBook(uint(id), claims.UserID); err != nil {
+	if err := ctrl.BookService.DeleteBook(c.Request.Context(), uint(id), claims.UserID); err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot delete book by this id"})
-    log.Printf("Book controller Delete error, service method DeleteBook. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "Book controller Delete error, service method DeleteBook", "error", err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
-func (ctrl *BookController) GetCreateMock(c *gin.Context) {
-	books := make([]models.Book, 200)
-	for i := range books {
-    books[i] = models.Book{
-        AuthorID: uint(i+1),
-				Title: "Book title" + strconv.Itoa(i+1),
-				Content: "Content Content Content Content Content Content Content Content" + strconv.Itoa(i+1),
+func parseBookFilter(c *gin.Context) (*models.BookFilter, error) {
+	var filter models.BookFilter
+	uintParams := map[string]*uint{
+		"min_words":   &filter.MinWords,
+		"max_words":   &filter.MaxWords,
+		"min_minutes": &filter.MinMinutes,
+		"max_minutes": &filter.MaxMinutes,
+	}
+	for name, dst := range uintParams {
+		if value := c.Query(name); value != "" {
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
 			}
+			*dst = uint(n)
+		}
 	}
-	for i := range books {
-		if err := ctrl.BookService.CreateBook(&books[i]); err != nil {
-			log.Printf("User controller GetCreateMock error, service method CreateUser. Error: %s", err.Error())
-			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create mock users"})
-			return
+	floatParams := map[string]**float64{
+		"min_readability": &filter.MinReadability,
+		"max_readability": &filter.MaxReadability,
+	}
+	for name, dst := range floatParams {
+		if value := c.Query(name); value != "" {
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			*dst = &f
 		}
 	}
-
-	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful create"})
+	filter.Language = c.Query("language")
+	return &filter, nil
 }
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ContributorController struct {
+	ContributorService services.ContributorService
+}
+
+func NewContributorController(service services.ContributorService) *ContributorController {
+	return &ContributorController{ContributorService: service}
+}
+
+func (ctrl *ContributorController) GetAll(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Contributor controller GetAll error, cast id to int", "error", err)
+		return
+	}
+	contributors, err := ctrl.ContributorService.ListContributors(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "book not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get contributors"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Contributor controller GetAll error, service method ListContributors", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: contributors})
+}
+
+func (ctrl *ContributorController) Invite(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Contributor controller Invite error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	var req models.InviteContributorReq
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent author_id and role (author, editor, translator or illustrator)"})
+		slog.WarnContext(c.Request.Context(), "Contributor controller Invite error, bind", "error", err)
+		return
+	}
+
+	if err := ctrl.ContributorService.InviteContributor(c.Request.Context(), uint(id), claims.UserID, &req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotPrimaryAuthor):
+			c.JSON(http.StatusForbidden, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		case errors.Is(err, services.ErrInvalidContributor):
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "invited author not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot invite contributor"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Contributor controller Invite error, service method InviteContributor", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful invite"})
+}
+
+func (ctrl *ContributorController) Accept(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Contributor controller Accept error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	if err := ctrl.ContributorService.AcceptInvitation(c.Request.Context(), uint(id), claims.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "no pending invitation for this book"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot accept invitation"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Contributor controller Accept error, service method AcceptInvitation", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful accept"})
+}
+
+func (ctrl *ContributorController) Remove(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Contributor controller Remove error, cast id to int", "error", err)
+		return
+	}
+	authorID, err := strconv.ParseUint(c.Param("author_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer author id"})
+		slog.WarnContext(c.Request.Context(), "Contributor controller Remove error, cast author id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	if err := ctrl.ContributorService.RemoveContributor(c.Request.Context(), uint(id), claims.UserID, uint(authorID)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotPrimaryAuthor):
+			c.JSON(http.StatusForbidden, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "contributor not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot remove contributor"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Contributor controller Remove error, service method RemoveContributor", "error", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ctrl *ContributorController) GetInvitations(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	invitations, err := ctrl.ContributorService.ListInvitations(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get invitations"})
+		slog.ErrorContext(c.Request.Context(), "Contributor controller GetInvitations error, service method ListInvitations", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: invitations})
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+	"github.com/Quavke/eBookReader/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CoverController struct {
+	CoverService services.CoverService
+	maxSize      int64
+}
+
+func NewCoverController(service services.CoverService, maxSize int64) *CoverController {
+	return &CoverController{CoverService: service, maxSize: maxSize}
+}
+
+func (ctrl *CoverController) Upload(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot cast id to integer"})
+		slog.WarnContext(c.Request.Context(), "Cover controller Upload error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	data, err := readUpload(c, "cover", ctrl.maxSize)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Cover controller Upload error, read upload", "error", err)
+		return
+	}
+
+	cover, err := ctrl.CoverService.UploadCover(c.Request.Context(), uint(id), claims.UserID, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCoverTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, models.APIResponse[any]{Message: "error", Error: "cover file is too large"})
+		case errors.Is(err, utils.ErrUnsupportedImage), errors.Is(err, utils.ErrImageTooLarge):
+			c.JSON(http.StatusUnsupportedMediaType, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		case errors.Is(err, services.ErrNotBookAuthor):
+			c.JSON(http.StatusForbidden, models.APIResponse[any]{Message: "error", Error: "you can change covers only of your own books"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot upload cover"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Cover controller Upload error, service method UploadCover", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful upload", Data: cover})
+}
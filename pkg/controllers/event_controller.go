@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/events"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+type EventController struct {
+	EventService services.EventService
+}
+
+func NewEventController(service services.EventService) *EventController {
+	return &EventController{EventService: service}
+}
+
+// Stream serves Server-Sent Events. Browsers resend the last id they saw in
+// the Last-Event-ID header on reconnect; the last_event_id query parameter
+// covers clients that open a fresh EventSource.
+func (ctrl *EventController) Stream(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	stream, err := ctrl.EventService.Subscribe(c.Request.Context(), claims.UserID, lastEventID)
+	if err != nil {
+		if errors.Is(err, events.ErrInvalidEventID) {
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot open event stream"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Event controller Stream error, service method Subscribe", "error", err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, ok := <-stream:
+			if !ok {
+				return
+			}
+			if err := events.WriteSSE(c.Writer, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := events.WriteHeartbeat(c.Writer); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
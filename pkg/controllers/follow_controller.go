@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type FollowController struct {
+	FollowService services.FollowService
+}
+
+func NewFollowController(service services.FollowService) *FollowController {
+	return &FollowController{FollowService: service}
+}
+
+func (ctrl *FollowController) Follow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Follow controller Follow error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	if err := ctrl.FollowService.Follow(c.Request.Context(), claims.UserID, uint(id)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrFollowSelf):
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "author not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot follow author"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Follow controller Follow error, service method Follow", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful follow"})
+}
+
+func (ctrl *FollowController) Unfollow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Follow controller Unfollow error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	if err := ctrl.FollowService.Unfollow(c.Request.Context(), claims.UserID, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "you do not follow this author"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot unfollow author"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Follow controller Unfollow error, service method Unfollow", "error", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ctrl *FollowController) GetFeed(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	limit, err := strconv.ParseUint(c.DefaultQuery("limit", "20"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer limit"})
+		slog.WarnContext(c.Request.Context(), "Follow controller GetFeed error, cast limit to int", "error", err)
+		return
+	}
+
+	feed, err := ctrl.FollowService.GetFeed(c.Request.Context(), claims.UserID, c.Query("cursor"), uint(limit))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get feed"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Follow controller GetFeed error, service method GetFeed", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: feed})
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Quavke/eBookReader/pkg/health"
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	Checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) *HealthController {
+	return &HealthController{Checker: checker}
+}
+
+// Live only tells that the process serves requests; it must not depend on
+// Postgres or Redis, or an outage there would get every instance restarted.
+func (ctrl *HealthController) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: health.Report{Status: health.StatusUp}})
+}
+
+func (ctrl *HealthController) Ready(c *gin.Context) {
+	report := ctrl.Checker.Ready(c.Request.Context())
+	if report.Status != health.StatusUp {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse[any]{Message: "error", Error: "not ready", Data: report})
+		if !ctrl.Checker.Draining() {
+			slog.WarnContext(c.Request.Context(), "Health controller Ready: not ready", "checks", report.Checks)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: report})
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	publicCacheControl  = "public, max-age=60, must-revalidate"
+	privateCacheControl = "private, no-store"
+)
+
+// notModified sets the validators and cache policy of a public GET response and
+// answers 304 when the client copy is still fresh.
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	c.Header("Cache-Control", publicCacheControl)
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if etag == "" || !etagListContains(inm, etag) {
+			return false
+		}
+		c.Status(http.StatusNotModified)
+		return true
+	}
+
+	ims := c.GetHeader("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	if lastModified.Truncate(time.Second).After(since) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagListContains uses the weak comparison required for If-None-Match.
+func etagListContains(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func paginationLastModified(p *models.Pagination) time.Time {
+	if p == nil || p.LastModified == nil {
+		return time.Time{}
+	}
+	return *p.LastModified
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Quavke/eBookReader/pkg/jobs"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobController struct {
+	JobService services.JobService
+}
+
+func NewJobController(service services.JobService) *JobController {
+	return &JobController{JobService: service}
+}
+
+func (ctrl *JobController) GetByID(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	job, err := ctrl.JobService.GetJob(c.Request.Context(), c.Param("id"), claims.UserID)
+	if err != nil {
+		if errors.Is(err, jobs.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get job"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Job controller GetByID error, service method GetJob", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: job})
+}
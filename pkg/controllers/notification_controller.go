@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotificationController struct {
+	NotificationService services.NotificationService
+}
+
+func NewNotificationController(service services.NotificationService) *NotificationController {
+	return &NotificationController{NotificationService: service}
+}
+
+func (ctrl *NotificationController) GetAll(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	limit, err := strconv.ParseUint(c.DefaultQuery("l", "50"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer limit"})
+		slog.WarnContext(c.Request.Context(), "Notification controller GetAll error, cast limit to int", "error", err)
+		return
+	}
+	page, err := strconv.ParseUint(c.DefaultQuery("p", "1"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer page"})
+		slog.WarnContext(c.Request.Context(), "Notification controller GetAll error, cast page to int", "error", err)
+		return
+	}
+	unreadOnly := c.Query("unread") == "true"
+
+	inbox, err := ctrl.NotificationService.GetInbox(c.Request.Context(), claims.UserID, uint(limit), uint(page), unreadOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get notifications"})
+		slog.ErrorContext(c.Request.Context(), "Notification controller GetAll error, service method GetInbox", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: inbox})
+}
+
+func (ctrl *NotificationController) MarkRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Notification controller MarkRead error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	if err := ctrl.NotificationService.MarkRead(c.Request.Context(), claims.UserID, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "notification not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot mark notification as read"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Notification controller MarkRead error, service method MarkRead", "error", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ctrl *NotificationController) MarkAllRead(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	if err := ctrl.NotificationService.MarkAllRead(c.Request.Context(), claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot mark notifications as read"})
+		slog.ErrorContext(c.Request.Context(), "Notification controller MarkAllRead error, service method MarkAllRead", "error", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ctrl *NotificationController) GetPreferences(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	prefs, err := ctrl.NotificationService.GetPreferences(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get notification preferences"})
+		slog.ErrorContext(c.Request.Context(), "Notification controller GetPreferences error, service method GetPreferences", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: prefs})
+}
+
+func (ctrl *NotificationController) UpdatePreferences(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	var req map[string]bool
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to send an object mapping notification types to true or false"})
+		slog.WarnContext(c.Request.Context(), "Notification controller UpdatePreferences error, bind", "error", err)
+		return
+	}
+
+	prefs, err := ctrl.NotificationService.UpdatePreferences(c.Request.Context(), claims.UserID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownNotificationType) {
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot update notification preferences"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Notification controller UpdatePreferences error, service method UpdatePreferences", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: prefs})
+}
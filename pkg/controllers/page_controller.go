@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PageController struct {
+	PaginatorService services.PaginatorService
+}
+
+func NewPageController(service services.PaginatorService) *PageController {
+	return &PageController{PaginatorService: service}
+}
+
+func (ctrl *PageController) GetPage(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Page controller GetPage error, cast id to int", "error", err)
+		return
+	}
+
+	size, err := strconv.ParseUint(c.DefaultQuery("size", "0"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "size must be a positive integer"})
+		slog.WarnContext(c.Request.Context(), "Page controller GetPage error, cast size to int", "error", err)
+		return
+	}
+	unit := c.Query("unit")
+
+	var page *models.BookPage
+	if offsetStr, ok := c.GetQuery("offset"); ok {
+		offset, convErr := strconv.Atoi(offsetStr)
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "offset must be an integer"})
+			slog.WarnContext(c.Request.Context(), "Page controller GetPage error, cast offset to int", "error", convErr)
+			return
+		}
+		page, err = ctrl.PaginatorService.GetPageAtOffset(c.Request.Context(), uint(id), uint(size), unit, offset)
+	} else {
+		pageNum, convErr := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+		if convErr != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "page must be a positive integer"})
+			slog.WarnContext(c.Request.Context(), "Page controller GetPage error, cast page to int", "error", convErr)
+			return
+		}
+		page, err = ctrl.PaginatorService.GetPage(c.Request.Context(), uint(id), uint(size), unit, uint(pageNum))
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPageUnit):
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "unit must be chars or words"})
+		case errors.Is(err, services.ErrInvalidPageSize):
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		case errors.Is(err, services.ErrPageOutOfRange):
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "page is out of range"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get book page"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Page controller GetPage error, service method GetPage", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: page})
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ifMatchVersion(c *gin.Context) (uint, bool) {
+	version, err := utils.ParseIfMatch(c.GetHeader("If-Match"))
+	if errors.Is(err, utils.ErrMissingIfMatch) {
+		c.JSON(http.StatusPreconditionRequired, models.APIResponse[any]{Message: "error", Error: "If-Match header with the current ETag is required"})
+		slog.WarnContext(c.Request.Context(), "Precondition error, missing If-Match", "method", c.Request.Method, "path", c.Request.URL.Path)
+		return 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "cannot parse If-Match header"})
+		slog.ErrorContext(c.Request.Context(), "Precondition error, parse If-Match", "error", err)
+		return 0, false
+	}
+	return version, true
+}
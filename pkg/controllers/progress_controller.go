@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+const (
+	syncWriteWait  = 10 * time.Second
+	syncPongWait   = 60 * time.Second
+	syncPingPeriod = syncPongWait * 9 / 10
+	maxDeviceID    = 64
+)
+
+// The default origin check is kept: the socket is authenticated by cookie,
+// so accepting any origin would let other sites open it on a user's behalf.
+var syncUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type ProgressController struct {
+	ProgressService services.ProgressService
+}
+
+func NewProgressController(service services.ProgressService) *ProgressController {
+	return &ProgressController{ProgressService: service}
+}
+
+func (ctrl *ProgressController) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Progress controller Get error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	progress, err := ctrl.ProgressService.GetProgress(c.Request.Context(), claims.UserID, uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoProgress):
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "book not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get reading progress"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Progress controller Get error, service method GetProgress", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: progress})
+}
+
+// Update answers 409 with the stored position when another device recorded a
+// newer one.
+func (ctrl *ProgressController) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Progress controller Update error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+	deviceID, ok := deviceIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.ProgressReq
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to send position, page and updated_at"})
+		slog.WarnContext(c.Request.Context(), "Progress controller Update error, bind", "error", err)
+		return
+	}
+
+	progress, applied, err := ctrl.ProgressService.UpdateProgress(c.Request.Context(), claims.UserID, uint(id), deviceID, &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "book not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot update reading progress"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Progress controller Update error, service method UpdateProgress", "error", err)
+		return
+	}
+	if !applied {
+		c.JSON(http.StatusConflict, models.APIResponse[any]{Message: "error", Error: "a newer position was recorded on another device", Data: progress})
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: progress})
+}
+
+// Sync upgrades to a WebSocket bound to one book. The server first sends the
+// stored position, then pushes positions from the user's other devices. Each
+// position the client sends is answered with an ack, or with a conflict
+// carrying the newer stored position.
+func (ctrl *ProgressController) Sync(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Progress controller Sync error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+	deviceID, ok := deviceIDParam(c)
+	if !ok {
+		return
+	}
+
+	current, err := ctrl.ProgressService.GetProgress(c.Request.Context(), claims.UserID, uint(id))
+	if err != nil && !errors.Is(err, services.ErrNoProgress) {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "book not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get reading progress"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Progress controller Sync error, service method GetProgress", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	remote, err := ctrl.ProgressService.Watch(ctx, claims.UserID, uint(id), deviceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot open sync session"})
+		slog.ErrorContext(c.Request.Context(), "Progress controller Sync error, service method Watch", "error", err)
+		return
+	}
+
+	conn, err := syncUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written an error response.
+		slog.ErrorContext(c.Request.Context(), "Progress controller Sync error, upgrade", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	replies := make(chan models.SyncMessage, 8)
+	go ctrl.readSync(ctx, cancel, conn, claims.UserID, uint(id), deviceID, replies)
+
+	if current != nil {
+		replies <- models.SyncMessage{Type: models.SyncProgress, Progress: current}
+	}
+	ping := time.NewTicker(syncPingPeriod)
+	defer ping.Stop()
+	for {
+		var msg models.SyncMessage
+		select {
+		case <-ctx.Done():
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(syncWriteWait))
+			return
+		case p, ok := <-remote:
+			if !ok {
+				return
+			}
+			msg = models.SyncMessage{Type: models.SyncProgress, Progress: &p}
+		case msg = <-replies:
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(syncWriteWait)); err != nil {
+				return
+			}
+			continue
+		}
+		conn.SetWriteDeadline(time.Now().Add(syncWriteWait))
+		if err := conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+// readSync owns the read side of a sync socket; gorilla allows one reader and
+// one writer at a time, so replies go back through the writer loop.
+func (ctrl *ProgressController) readSync(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, userID, bookID uint, deviceID string, replies chan<- models.SyncMessage) {
+	defer cancel()
+	conn.SetReadLimit(1024)
+	conn.SetReadDeadline(time.Now().Add(syncPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(syncPongWait))
+	})
+	for {
+		var req models.ProgressReq
+		if err := conn.ReadJSON(&req); err != nil {
+			if _, ok := err.(*websocket.CloseError); !ok && !errors.Is(err, context.Canceled) {
+				slog.ErrorContext(ctx, "Progress controller Sync error, read", "error", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(syncPongWait))
+
+		var reply models.SyncMessage
+		progress, applied, err := ctrl.ProgressService.UpdateProgress(ctx, userID, bookID, deviceID, &req)
+		switch {
+		case err != nil:
+			slog.ErrorContext(ctx, "Progress controller Sync error, service method UpdateProgress", "error", err)
+			reply = models.SyncMessage{Type: models.SyncError, Error: "cannot update reading progress"}
+		case applied:
+			reply = models.SyncMessage{Type: models.SyncAck, Progress: progress}
+		default:
+			reply = models.SyncMessage{Type: models.SyncConflict, Progress: progress}
+		}
+		select {
+		case replies <- reply:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func deviceIDParam(c *gin.Context) (string, bool) {
+	deviceID := c.Query("device_id")
+	if deviceID == "" || len(deviceID) > maxDeviceID {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "device_id query parameter is required and must be at most 64 characters"})
+		return "", false
+	}
+	return deviceID, true
+}
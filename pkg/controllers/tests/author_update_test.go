@@ -0,0 +1,65 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/controllers"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturedAuthors struct {
+	services.AuthorService
+	last *models.UpdateAuthorReq
+}
+
+func (s *capturedAuthors) UpdateAuthor(ctx context.Context, author *models.UpdateAuthorReq, id, version uint) error {
+	s.last = author
+	return nil
+}
+
+func TestAuthorUpdate_ClearFields(t *testing.T) {
+	authors := &capturedAuthors{}
+	router := gin.New()
+	router.PUT("/authors/me", withClaims, controllers.NewAuthorController(authors, 0).Update)
+
+	put := func(body string) int {
+		authors.last = nil
+		req := httptest.NewRequest(http.MethodPut, "/authors/me", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"1"`)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Пустая строка очищает поле, а не пропускается
+	require.Equal(t, http.StatusOK, put(`{"bio":"","website":"","country":""}`))
+	require.NotNil(t, authors.last)
+	for _, field := range []*string{authors.last.Bio, authors.last.Website, authors.last.Country} {
+		if assert.NotNil(t, field) {
+			assert.Empty(t, *field)
+		}
+	}
+
+	// Не переданные поля остаются нетронутыми
+	require.Equal(t, http.StatusOK, put(`{"bio":"Novelist"}`))
+	assert.Equal(t, "Novelist", *authors.last.Bio)
+	assert.Nil(t, authors.last.Website)
+	assert.Nil(t, authors.last.Country)
+
+	assert.Equal(t, http.StatusOK, put(`{}`))
+	assert.Nil(t, authors.last)
+
+	assert.Equal(t, http.StatusBadRequest, put(`{"website":"not a url"}`))
+	assert.Equal(t, http.StatusBadRequest, put(`{"country":"Narnia"}`))
+	assert.Equal(t, http.StatusOK, put(`{"website":"https://example.org","country":"GB"}`))
+}
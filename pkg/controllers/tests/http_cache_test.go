@@ -0,0 +1,162 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/controllers"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bookUpdated has a fractional second, which Last-Modified cannot carry.
+var bookUpdated = time.Date(2024, 5, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+// cachedBooks serves one book at version 4 and a list of it, so the
+// validators stay the same between requests.
+type cachedBooks struct {
+	services.BookService
+	listModified *time.Time
+}
+
+func (s *cachedBooks) GetBookByID(ctx context.Context, id uint) (*models.BookResp, error) {
+	return &models.BookResp{ID: id, Title: "Emma", Version: 4, UpdatedAt: bookUpdated}, nil
+}
+
+func (s *cachedBooks) GetAllBooks(ctx context.Context, limit, page uint, sort string, filter *models.BookFilter) (*models.Pagination, error) {
+	return &models.Pagination{
+		Limit:        limit,
+		Page:         page,
+		TotalRows:    1,
+		TotalPages:   1,
+		Rows:         []models.BookResp{{ID: 1, Title: "Emma", Version: 4}},
+		LastModified: s.listModified,
+	}, nil
+}
+
+func cacheRouter(books services.BookService) *gin.Engine {
+	ctrl := controllers.NewBookController(books)
+	router := gin.New()
+	router.GET("/books", ctrl.GetAll)
+	router.GET("/books/:id", ctrl.GetByID)
+	return router
+}
+
+func getWith(router *gin.Engine, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestNotModified_Book(t *testing.T) {
+	router := cacheRouter(&cachedBooks{})
+	lastModified := bookUpdated.Format(http.TimeFormat)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		code    int
+	}{
+		{name: "no validators", code: http.StatusOK},
+		{name: "matching etag", headers: map[string]string{"If-None-Match": `"4"`}, code: http.StatusNotModified},
+		{name: "weak etag matches strong", headers: map[string]string{"If-None-Match": `W/"4"`}, code: http.StatusNotModified},
+		{name: "etag in list", headers: map[string]string{"If-None-Match": `"2", W/"3",  "4"`}, code: http.StatusNotModified},
+		{name: "wildcard", headers: map[string]string{"If-None-Match": "*"}, code: http.StatusNotModified},
+		{name: "other etag", headers: map[string]string{"If-None-Match": `"3"`}, code: http.StatusOK},
+		{name: "unquoted etag", headers: map[string]string{"If-None-Match": "4"}, code: http.StatusOK},
+		// If-None-Match wins over If-Modified-Since
+		{name: "other etag, fresh date", headers: map[string]string{"If-None-Match": `"3"`, "If-Modified-Since": lastModified}, code: http.StatusOK},
+		{name: "same second", headers: map[string]string{"If-Modified-Since": lastModified}, code: http.StatusNotModified},
+		{name: "later date", headers: map[string]string{"If-Modified-Since": bookUpdated.Add(time.Hour).Format(http.TimeFormat)}, code: http.StatusNotModified},
+		{name: "earlier date", headers: map[string]string{"If-Modified-Since": bookUpdated.Add(-time.Second).Format(http.TimeFormat)}, code: http.StatusOK},
+		{name: "malformed date", headers: map[string]string{"If-Modified-Since": "yesterday"}, code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getWith(router, "/books/1", tt.headers)
+
+			assert.Equal(t, tt.code, w.Code)
+			// Валидаторы отдаются и в ответе 304
+			assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			assert.Equal(t, lastModified, w.Header().Get("Last-Modified"))
+			assert.Equal(t, "public, max-age=60, must-revalidate", w.Header().Get("Cache-Control"))
+			if tt.code == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNotModified_List(t *testing.T) {
+	first := getWith(cacheRouter(&cachedBooks{}), "/books", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	// Пустой список не знает даты изменения
+	assert.Empty(t, first.Header().Get("Last-Modified"))
+
+	modified := bookUpdated
+	tests := []struct {
+		name     string
+		modified *time.Time
+		headers  map[string]string
+		code     int
+	}{
+		{name: "same list", headers: map[string]string{"If-None-Match": etag}, code: http.StatusNotModified},
+		{name: "other page", headers: map[string]string{"If-None-Match": `"0000"`}, code: http.StatusOK},
+		{name: "date without last modified", headers: map[string]string{"If-Modified-Since": bookUpdated.Format(http.TimeFormat)}, code: http.StatusOK},
+		{name: "date with last modified", modified: &modified, headers: map[string]string{"If-Modified-Since": bookUpdated.Format(http.TimeFormat)}, code: http.StatusNotModified},
+		{name: "stale date", modified: &modified, headers: map[string]string{"If-Modified-Since": bookUpdated.Add(-time.Minute).Format(http.TimeFormat)}, code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getWith(cacheRouter(&cachedBooks{listModified: tt.modified}), "/books", tt.headers)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+// cachedUsers serves one user at version 2; isAuthor changes without a new version.
+type cachedUsers struct {
+	services.UserService
+	isAuthor bool
+}
+
+func (s *cachedUsers) GetUserByID(ctx context.Context, id uint) (*models.UserResp, error) {
+	return &models.UserResp{Username: "emma", IsAuthor: s.isAuthor, Version: 2, UpdatedAt: bookUpdated}, nil
+}
+
+func TestNotModified_User(t *testing.T) {
+	users := &cachedUsers{}
+	ctrl := controllers.NewUserController(users)
+	router := gin.New()
+	router.GET("/users/:id", ctrl.GetByID)
+
+	first := getWith(router, "/users/1", nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	// Тег начинается с версии, чтобы If-Match на PUT /users/me продолжал работать
+	assert.Regexp(t, `^"2-`, etag)
+	assert.Equal(t, bookUpdated.Format(http.TimeFormat), first.Header().Get("Last-Modified"))
+
+	w := getWith(router, "/users/1", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// Пользователь стал автором — закэшированная копия устарела
+	users.isAuthor = true
+	w = getWith(router, "/users/1", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
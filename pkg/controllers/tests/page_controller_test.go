@@ -0,0 +1,55 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/controllers"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// missingBooks finds no book at all, so any size that passes the bounds
+// check ends in a 500 rather than a page.
+type missingBooks struct {
+	repositories.BookRepo
+	lookups int
+}
+
+func (r *missingBooks) GetByID(ctx context.Context, id uint) (*models.Book, error) {
+	r.lookups++
+	return nil, context.Canceled
+}
+
+func TestGetPage_SizeBounds(t *testing.T) {
+	books := &missingBooks{}
+	router := gin.New()
+	router.GET("/books/:id/pages", controllers.NewPageController(services.NewPaginatorService(books, nil, 0, "", 100, 5000)).GetPage)
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{name: "default", query: "", code: http.StatusInternalServerError},
+		{name: "lower bound", query: "?size=100", code: http.StatusInternalServerError},
+		{name: "upper bound", query: "?size=5000&offset=0", code: http.StatusInternalServerError},
+		{name: "too small", query: "?size=1", code: http.StatusBadRequest},
+		{name: "too large", query: "?size=5001", code: http.StatusBadRequest},
+		{name: "too large at offset", query: "?size=4000000000&offset=10", code: http.StatusBadRequest},
+		{name: "negative", query: "?size=-1", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books/1/pages"+tt.query, nil))
+		assert.Equal(t, tt.code, w.Code, tt.name)
+	}
+	// Размер вне диапазона отклоняется до обращения к базе
+	assert.Equal(t, 3, books.lookups)
+}
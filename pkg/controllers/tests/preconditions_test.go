@@ -0,0 +1,110 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/controllers"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// versionedBooks keeps one book whose version grows with every update, as
+// the repository does.
+type versionedBooks struct {
+	services.BookService
+	version uint
+	updates int
+}
+
+func (s *versionedBooks) UpdateBook(ctx context.Context, book *models.Book, id, userID, version uint) error {
+	if version != s.version {
+		return repositories.ErrVersionMismatch
+	}
+	s.version++
+	s.updates++
+	return nil
+}
+
+func withClaims(c *gin.Context) {
+	c.Set("claims", &models.Claims{UserID: 1})
+}
+
+func TestBookUpdate_Preconditions(t *testing.T) {
+	books := &versionedBooks{version: 3}
+	router := gin.New()
+	router.PUT("/books/:id", withClaims, controllers.NewBookController(books).Update)
+
+	put := func(ifMatch string) int {
+		req := httptest.NewRequest(http.MethodPut, "/books/1", strings.NewReader(`{"title":"Emma","content":"Emma Woodhouse, handsome, clever, and rich."}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Без If-Match обновление запрещено
+	assert.Equal(t, http.StatusPreconditionRequired, put(""))
+	assert.Equal(t, http.StatusBadRequest, put("*"))
+	assert.Equal(t, http.StatusBadRequest, put(`W/"3"`))
+
+	assert.Equal(t, http.StatusOK, put(`"3"`))
+	// Повтор с тем же устаревшим тегом не должен выглядеть успешным
+	assert.Equal(t, http.StatusPreconditionFailed, put(`"3"`))
+	assert.Equal(t, http.StatusPreconditionFailed, put(`"3"`))
+	assert.Equal(t, http.StatusOK, put(`"4-0a1b2c"`))
+	assert.Equal(t, 2, books.updates)
+}
+
+type versionedAuthors struct {
+	services.AuthorService
+	version uint
+}
+
+func (s *versionedAuthors) UpdateAuthor(ctx context.Context, author *models.UpdateAuthorReq, id, version uint) error {
+	if version != s.version {
+		return repositories.ErrVersionMismatch
+	}
+	s.version++
+	return nil
+}
+
+func TestAuthorUpdate_Preconditions(t *testing.T) {
+	router := gin.New()
+	router.PUT("/authors/me", withClaims, controllers.NewAuthorController(&versionedAuthors{version: 1}, 0).Update)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		code    int
+	}{
+		{name: "missing", code: http.StatusPreconditionRequired},
+		{name: "current", ifMatch: `"1"`, code: http.StatusOK},
+		{name: "stale", ifMatch: `"1"`, code: http.StatusPreconditionFailed},
+		{name: "list", ifMatch: `"2", "3"`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPut, "/authors/me", strings.NewReader(`{"bio":"Novelist"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.code, w.Code, tt.name)
+	}
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransferController struct {
+	TransferService services.TransferService
+	maxImportSize   int64
+}
+
+func NewTransferController(service services.TransferService, maxImportSize int64) *TransferController {
+	return &TransferController{TransferService: service, maxImportSize: maxImportSize}
+}
+
+// Import answers 202 with a job to poll at GET /jobs/:id; its result holds
+// the id of the created book.
+func (ctrl *TransferController) Import(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	data, err := readUpload(c, "file", ctrl.maxImportSize)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Transfer controller Import error, read upload", "error", err)
+		return
+	}
+
+	job, err := ctrl.TransferService.ImportEPUB(c.Request.Context(), claims.UserID, data)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEPUB) {
+			c.JSON(http.StatusUnsupportedMediaType, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot start import"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Transfer controller Import error, service method ImportEPUB", "error", err)
+		return
+	}
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, models.APIResponse[any]{Message: "import started", Data: job})
+}
+
+// Export answers 202 with a job to poll at GET /jobs/:id; its result holds
+// the download url of a zip archive.
+func (ctrl *TransferController) Export(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	var req models.ExportReq
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You can send book_ids (at most 500) and format (txt or html)"})
+		slog.WarnContext(c.Request.Context(), "Transfer controller Export error, bind", "error", err)
+		return
+	}
+
+	job, err := ctrl.TransferService.ExportBooks(c.Request.Context(), claims.UserID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedFormat) {
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot start export"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Transfer controller Export error, service method ExportBooks", "error", err)
+		return
+	}
+	c.Header("Location", "/api/v1/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, models.APIResponse[any]{Message: "export started", Data: job})
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errUploadTooLarge = errors.New("uploaded file is too large")
+
+// readUpload reads a single multipart file field of at most maxSize bytes and
+// writes the error response itself when the upload is unusable.
+func readUpload(c *gin.Context, field string, maxSize int64) ([]byte, error) {
+	// Leave room for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+64<<10)
+	file, _, err := c.Request.FormFile(field)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, models.APIResponse[any]{Message: "error", Error: field + " file is too large"})
+		} else {
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent multipart field " + field})
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "cannot read " + field + " file"})
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, models.APIResponse[any]{Message: "error", Error: field + " file is too large"})
+		return nil, errUploadTooLarge
+	}
+	return data, nil
+}
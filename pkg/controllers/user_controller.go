@@ -1,12 +1,14 @@
 package controllers
 
 import (
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
 
 	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
 	"github.com/Quavke/eBookReader/pkg/services"
 	"github.com/Quavke/eBookReader/pkg/utils"
 
@@ -28,24 +30,31 @@ func (ctrl *UserController) GetAll(c *gin.Context){
 	limit, err := strconv.ParseUint(limitStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer limit"})
-		log.Printf("Author controller GetAll error, cast limit to int. Error: %s", err.Error())
+		slog.WarnContext(c.Request.Context(), "Author controller GetAll error, cast limit to int", "error", err)
 		return
 	}
 
 	page, err := strconv.ParseUint(pageStr, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer page"})
-		log.Printf("Author controller GetAll error, cast page to int. Error: %s", err.Error())
+		slog.WarnContext(c.Request.Context(), "Author controller GetAll error, cast page to int", "error", err)
 		return
 	}
 
-	users, err := ctrl.UserService.GetAllUsers(uint(limit), uint(page), "id desc")
+	users, err := ctrl.UserService.GetAllUsers(c.Request.Context(), uint(limit), uint(page), "id desc")
 	if err != nil{
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get all users"})
-		log.Printf("User controller GetAll error, service method GetAllUsers. Error: %s", err.Error())
+		slog.ErrorContext(c.Request.Context(), "User controller GetAll error, service method GetAllUsers", "error", err)
 		return
 	}
 	
+	etag, err := utils.StrongETag(users)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "User controller GetAll error, build ETag", "error", err)
+	}
+	if notModified(c, etag, paginationLastModified(users)) {
+		return
+	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "Success", Data: users})
 }
 
@@ -54,13 +63,22 @@ func (ctrl *UserController) GetByID(c *gin.Context){
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot cast id to integer"})
-    log.Printf("User controller GetByID error, cast id to int. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "User controller GetByID error, cast id to int", "error", err)
 		return
 	}
-	user, err := ctrl.UserService.GetUserByID(uint(id))
+	user, err := ctrl.UserService.GetUserByID(c.Request.Context(), uint(id))
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot user by this ID"})
-		log.Printf("User controller GetByID error, service method GetUserByID. Error: %s", err.Error())
+		slog.ErrorContext(c.Request.Context(), "User controller GetByID error, service method GetUserByID", "error", err)
+		return
+	}
+	// Becoming an author does not bump the user version, so the tag also
+	// hashes the response; it still starts with the version for If-Match.
+	etag, err := utils.CompositeETag(user.Version, user)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "User controller GetByID error, build ETag", "error", err)
+	}
+	if notModified(c, etag, user.UpdatedAt) {
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "Successful", Data: user})
@@ -70,13 +88,13 @@ func (ctrl *UserController) Create(c *gin.Context){
 	var user models.RegisterReq
 	if err := c.ShouldBindJSON(&user); err != nil {
     c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent Username and Password"})
-		log.Printf("User controller Create error, bind. Error: %s", err.Error())
+		slog.WarnContext(c.Request.Context(), "User controller Create error, bind", "error", err)
 		return
 	}
 
-	if err := ctrl.UserService.CreateUser(user.Username, []byte(user.Password)); err != nil {
+	if err := ctrl.UserService.CreateUser(c.Request.Context(), user.Username, []byte(user.Password)); err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create user"})
-		log.Printf("User controller Create error, repo method CreateUser. Error: %s", err.Error())
+		slog.ErrorContext(c.Request.Context(), "User controller Create error, repo method CreateUser", "error", err)
 		return
 	}
 	user.Password = ""
@@ -88,29 +106,31 @@ func (ctrl *UserController) Login(c *gin.Context){
 	var user models.RegisterReq
 	if err := c.ShouldBindJSON(&user); err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent Username and Password"})
-		log.Printf("User controller Login error, bind. Error: %s", err.Error())
+		slog.WarnContext(c.Request.Context(), "User controller Login error, bind", "error", err)
 		return
 	}
 
-	claims, err := ctrl.UserService.LoginUser(&user)
+	claims, err := ctrl.UserService.LoginUser(c.Request.Context(), &user)
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot login user"})
-		log.Printf("User controller Login error, repo method LoginUser. Error: %s", err.Error())
+		slog.ErrorContext(c.Request.Context(), "User controller Login error, repo method LoginUser", "error", err)
 		return
 	}
 
 	token, err := utils.GenerateToken(claims, []byte(os.Getenv("JWT_SECRET")))
 	if err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot generate token"})
-		log.Printf("User controller Login error, gen token. Error: %s", err.Error())
+		slog.ErrorContext(c.Request.Context(), "User controller Login error, gen token", "error", err)
 		return
 	}
+	c.Header("Cache-Control", privateCacheControl)
 	c.SetCookie("Authorization", token, 86400, "/", "", false, true) 
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "Successful login"})
 }
 
 func (ctrl *UserController) Logout(c *gin.Context){
 	isProd := c.MustGet("isProd").(bool)
+	c.Header("Cache-Control", privateCacheControl)
 	c.SetCookie("Authorization", "", -1, "/", "", isProd, true)
 
 	c.Status(http.StatusNoContent)
@@ -120,14 +140,24 @@ func (ctrl *UserController) Update(c *gin.Context){
 	claims := c.MustGet("claims").(*models.Claims)
 	var user models.UpdateReq
 
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	if err := c.ShouldBindJSON(&user); err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent Username and Password"})
-    log.Printf("User controller Update error, bind. Error: %s", err.Error())
+    slog.WarnContext(c.Request.Context(), "User controller Update error, bind", "error", err)
 		return
 	}
-	if err := ctrl.UserService.UpdateUser(&user, claims.UserID); err != nil {
+	if err := ctrl.UserService.UpdateUser(c.Request.Context(), &user, claims.UserID, version); err != nil {
+		if errors.Is(err, repositories.ErrVersionMismatch) {
+			c.JSON(http.StatusPreconditionFailed, models.APIResponse[any]{Message: "error", Error: "user was modified by someone else, reload it and try again"})
+			slog.WarnContext(c.Request.Context(), "User controller Update error, version is stale", "version", version, "error", err)
+			return
+		}
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot update user"})
-    log.Printf("User controller Update error, service method UpdateUser. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "User controller Update error, service method UpdateUser", "error", err)
 		return
 	}
   c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful update"})
@@ -136,30 +166,11 @@ func (ctrl *UserController) Update(c *gin.Context){
 func (ctrl *UserController) Delete(c *gin.Context){
 	claims := c.MustGet("claims").(*models.Claims)
 	isProd := c.MustGet("isProd").(bool)
-	if err := ctrl.UserService.DeleteUser(claims.UserID); err != nil {
+	if err := ctrl.UserService.DeleteUser(c.Request.Context(), claims.UserID); err != nil {
     c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
-    log.Printf("User controller Delete error, service method DeleteUser. Error: %s", err.Error())
+    slog.ErrorContext(c.Request.Context(), "User controller Delete error, service method DeleteUser", "error", err)
 		return
 	}
 	c.SetCookie("Authorization", "", -1, "/", "", isProd, true)
 	c.Status(http.StatusNoContent)
-}
-
-func (ctrl *UserController) GetCreateMock(c *gin.Context) {
-	users := make([]models.RegisterReq, 200)
-	for i := range users {
-    users[i] = models.RegisterReq{
-        Username: "user" + strconv.Itoa(i+1),
-				Password: "password" + strconv.Itoa(i+1),
-    }
-	}
-	for i := range users {
-		if err := ctrl.UserService.CreateUser(users[i].Username, []byte(users[i].Password)); err != nil {
-			log.Printf("User controller GetCreateMock error, service method CreateUser. Error: %s", err.Error())
-			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create mock users"})
-			return
-		}
-	}
-
-	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful create"})
 }
\ No newline at end of file
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookController struct {
+	WebhookService services.WebhookService
+}
+
+func NewWebhookController(service services.WebhookService) *WebhookController {
+	return &WebhookController{WebhookService: service}
+}
+
+func (ctrl *WebhookController) Create(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	var req models.CreateWebhookReq
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to send url and events (book.created, book.updated, book.published or book.deleted)"})
+		slog.WarnContext(c.Request.Context(), "Webhook controller Create error, bind", "error", err)
+		return
+	}
+
+	hook, err := ctrl.WebhookService.CreateWebhook(c.Request.Context(), claims.UserID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidWebhookURL):
+			// The wrapped cause names resolved addresses, keep it in the log.
+			c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: services.ErrInvalidWebhookURL.Error()})
+		case errors.Is(err, services.ErrWebhookForbidden):
+			c.JSON(http.StatusForbidden, models.APIResponse[any]{Message: "error", Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create webhook"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Webhook controller Create error, service method CreateWebhook", "error", err)
+		return
+	}
+	c.JSON(http.StatusCreated, models.APIResponse[any]{Message: "successful", Data: hook})
+}
+
+func (ctrl *WebhookController) GetAll(c *gin.Context) {
+	claims := c.MustGet("claims").(*models.Claims)
+
+	hooks, err := ctrl.WebhookService.ListWebhooks(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get webhooks"})
+		slog.ErrorContext(c.Request.Context(), "Webhook controller GetAll error, service method ListWebhooks", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: hooks})
+}
+
+func (ctrl *WebhookController) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Webhook controller Delete error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	if err := ctrl.WebhookService.DeleteWebhook(c.Request.Context(), uint(id), claims.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "webhook not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot delete webhook"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Webhook controller Delete error, service method DeleteWebhook", "error", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (ctrl *WebhookController) GetDeliveries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Webhook controller GetDeliveries error, cast id to int", "error", err)
+		return
+	}
+	limit, err := strconv.ParseUint(c.DefaultQuery("l", "50"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer limit"})
+		slog.WarnContext(c.Request.Context(), "Webhook controller GetDeliveries error, cast limit to int", "error", err)
+		return
+	}
+	page, err := strconv.ParseUint(c.DefaultQuery("p", "1"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer page"})
+		slog.WarnContext(c.Request.Context(), "Webhook controller GetDeliveries error, cast page to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	deliveries, err := ctrl.WebhookService.ListDeliveries(c.Request.Context(), uint(id), claims.UserID, uint(limit), uint(page))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "webhook not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get webhook deliveries"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Webhook controller GetDeliveries error, service method ListDeliveries", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: deliveries})
+}
+
+// Test answers 200 even when the endpoint fails; the outcome is in the
+// returned delivery.
+func (ctrl *WebhookController) Test(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Webhook controller Test error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	delivery, err := ctrl.WebhookService.TestWebhook(c.Request.Context(), uint(id), claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "webhook not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot test webhook"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Webhook controller Test error, service method TestWebhook", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: delivery})
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+	"github.com/Quavke/eBookReader/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkController struct {
+	WorkService services.WorkService
+}
+
+func NewWorkController(service services.WorkService) *WorkController {
+	return &WorkController{WorkService: service}
+}
+
+func (ctrl *WorkController) GetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Work controller GetByID error, cast id to int", "error", err)
+		return
+	}
+	work, err := ctrl.WorkService.GetWork(c.Request.Context(), uint(id), c.GetHeader("Accept-Language"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "work not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot get work by this id"})
+		}
+		slog.ErrorContext(c.Request.Context(), "Work controller GetByID error, service method GetWork", "error", err)
+		return
+	}
+
+	// The chosen edition depends on Accept-Language, so caches must key on it.
+	c.Header("Vary", "Accept-Language")
+	var lastModified time.Time
+	if work.Edition != nil {
+		c.Header("Content-Language", work.Edition.Language)
+		lastModified = work.Edition.UpdatedAt
+	}
+	etag, err := utils.StrongETag(work)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Work controller GetByID error, build ETag", "error", err)
+	}
+	if notModified(c, etag, lastModified) {
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful", Data: work})
+}
+
+func (ctrl *WorkController) LinkTranslation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Work controller LinkTranslation error, cast id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	var req models.LinkTranslationReq
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: "something wrong with your request. You need to sent book_id of the translation"})
+		slog.WarnContext(c.Request.Context(), "Work controller LinkTranslation error, bind", "error", err)
+		return
+	}
+
+	work, err := ctrl.WorkService.LinkTranslation(c.Request.Context(), uint(id), req.BookID, claims.UserID)
+	if err != nil {
+		writeWorkError(c, err, "cannot link translation")
+		slog.ErrorContext(c.Request.Context(), "Work controller LinkTranslation error, service method LinkTranslation", "error", err)
+		return
+	}
+	c.JSON(http.StatusOK, models.APIResponse[any]{Message: "successful link", Data: work})
+}
+
+func (ctrl *WorkController) UnlinkTranslation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer id"})
+		slog.WarnContext(c.Request.Context(), "Work controller UnlinkTranslation error, cast id to int", "error", err)
+		return
+	}
+	translationID, err := strconv.ParseUint(c.Param("translation_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: "cannot create integer translation id"})
+		slog.WarnContext(c.Request.Context(), "Work controller UnlinkTranslation error, cast translation id to int", "error", err)
+		return
+	}
+	claims := c.MustGet("claims").(*models.Claims)
+
+	if err := ctrl.WorkService.UnlinkTranslation(c.Request.Context(), uint(id), uint(translationID), claims.UserID); err != nil {
+		writeWorkError(c, err, "cannot unlink translation")
+		slog.ErrorContext(c.Request.Context(), "Work controller UnlinkTranslation error, service method UnlinkTranslation", "error", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeWorkError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrNotBookAuthor):
+		c.JSON(http.StatusForbidden, models.APIResponse[any]{Message: "error", Error: "you can link only books you are allowed to edit"})
+	case errors.Is(err, repositories.ErrSameEdition), errors.Is(err, repositories.ErrOriginalEdition), errors.Is(err, repositories.ErrOriginalOfWork):
+		c.JSON(http.StatusBadRequest, models.APIResponse[any]{Message: "error", Error: err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, models.APIResponse[any]{Message: "error", Error: "book is not linked to this work"})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "error", Error: fallback})
+	}
+}
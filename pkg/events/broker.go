@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+var ErrInvalidEventID = errors.New("invalid event id")
+
+type Broker interface {
+	Publish(ctx context.Context, userID uint, eventType string, data any) error
+	// Subscribe replays events newer than lastEventID, if any, then delivers
+	// live events until ctx is cancelled, when the channel is closed.
+	Subscribe(ctx context.Context, userID uint, lastEventID string) (<-chan models.Event, error)
+}
+
+// CompareIDs orders event ids of the form "<millis>-<seq>" as Redis stream
+// ids are ordered.
+func CompareIDs(a, b string) (int, error) {
+	aMs, aSeq, err := splitID(a)
+	if err != nil {
+		return 0, err
+	}
+	bMs, bSeq, err := splitID(b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case aMs < bMs || aMs == bMs && aSeq < bSeq:
+		return -1, nil
+	case aMs == bMs && aSeq == bSeq:
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func splitID(id string) (uint64, uint64, error) {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, ErrInvalidEventID
+	}
+	m, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidEventID
+	}
+	s, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidEventID
+	}
+	return m, s, nil
+}
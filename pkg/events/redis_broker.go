@@ -0,0 +1,187 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	channelPrefix = "events:user:"
+	// retention is how many events per user stay available for resume.
+	retention = 500
+	// buffer is how many live events a slow connection may lag behind before
+	// they are dropped.
+	buffer = 64
+)
+
+// RedisBroker keeps recent events in a per-user Redis stream for resume and
+// fans live events out through pub/sub, so every API instance can deliver to
+// the connections it holds. Each instance uses a single pattern subscription.
+type RedisBroker struct {
+	client *redis.Client
+	mu     sync.Mutex
+	subs   map[uint]map[chan models.Event]struct{}
+}
+
+var _ Broker = (*RedisBroker)(nil)
+
+func NewRedisBroker(ctx context.Context, client *redis.Client) *RedisBroker {
+	b := &RedisBroker{
+		client: client,
+		subs:   make(map[uint]map[chan models.Event]struct{}),
+	}
+	go b.listen(ctx)
+	return b
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, userID uint, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	id, err := b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(userID),
+		MaxLen: retention,
+		Approx: true,
+		Values: map[string]any{"type": eventType, "data": payload},
+	}).Result()
+	if err != nil {
+		return err
+	}
+	msg, err := json.Marshal(models.Event{ID: id, Type: eventType, Data: payload})
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, channelKey(userID), msg).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, userID uint, lastEventID string) (<-chan models.Event, error) {
+	if lastEventID != "" {
+		if _, _, err := splitID(lastEventID); err != nil {
+			return nil, err
+		}
+	}
+	// Register before reading the backlog so nothing published in between is
+	// lost; duplicates are filtered by id below.
+	live := make(chan models.Event, buffer)
+	b.mu.Lock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[chan models.Event]struct{})
+	}
+	b.subs[userID][live] = struct{}{}
+	b.mu.Unlock()
+
+	var backlog []models.Event
+	if lastEventID != "" {
+		var err error
+		backlog, err = b.replay(ctx, userID, lastEventID)
+		if err != nil {
+			b.unsubscribe(userID, live)
+			return nil, err
+		}
+	}
+
+	out := make(chan models.Event)
+	go func() {
+		defer close(out)
+		defer b.unsubscribe(userID, live)
+		last := lastEventID
+		for _, e := range backlog {
+			select {
+			case out <- e:
+				last = e.ID
+			case <-ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case e := <-live:
+				if last != "" {
+					if cmp, err := CompareIDs(e.ID, last); err != nil || cmp <= 0 {
+						continue
+					}
+				}
+				select {
+				case out <- e:
+					last = e.ID
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (b *RedisBroker) replay(ctx context.Context, userID uint, lastEventID string) ([]models.Event, error) {
+	msgs, err := b.client.XRange(ctx, streamKey(userID), "("+lastEventID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	backlog := make([]models.Event, 0, len(msgs))
+	for _, m := range msgs {
+		eventType, _ := m.Values["type"].(string)
+		data, _ := m.Values["data"].(string)
+		backlog = append(backlog, models.Event{ID: m.ID, Type: eventType, Data: json.RawMessage(data)})
+	}
+	return backlog, nil
+}
+
+func (b *RedisBroker) listen(ctx context.Context) {
+	pubsub := b.client.PSubscribe(ctx, channelPrefix+"*")
+	// Closing the subscription is what ends the loop below.
+	stop := context.AfterFunc(ctx, func() { pubsub.Close() })
+	defer stop()
+	for msg := range pubsub.Channel() {
+		userID, err := strconv.ParseUint(strings.TrimPrefix(msg.Channel, channelPrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		var e models.Event
+		if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+			slog.ErrorContext(ctx, "Redis broker error, decode event", "error", err)
+			continue
+		}
+		b.dispatch(uint(userID), e)
+	}
+}
+
+func (b *RedisBroker) dispatch(userID uint, e models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[userID] {
+		select {
+		case ch <- e:
+		default:
+			slog.Warn("Redis broker: dropped event, connection is too slow", "event_id", e.ID, "user_id", userID)
+		}
+	}
+}
+
+func (b *RedisBroker) unsubscribe(userID uint, ch chan models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs[userID], ch)
+	if len(b.subs[userID]) == 0 {
+		delete(b.subs, userID)
+	}
+}
+
+func streamKey(userID uint) string {
+	return fmt.Sprintf("events:stream:%d", userID)
+}
+
+func channelKey(userID uint) string {
+	return fmt.Sprintf("%s%d", channelPrefix, userID)
+}
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+// WriteSSE encodes e in the text/event-stream format. Data is JSON and so has
+// no raw newlines, but they are split defensively as the spec requires.
+func WriteSSE(w io.Writer, e models.Event) error {
+	var b strings.Builder
+	if e.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", e.ID)
+	}
+	if e.Type != "" {
+		fmt.Fprintf(&b, "event: %s\n", e.Type)
+	}
+	for _, line := range strings.Split(string(e.Data), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHeartbeat sends an SSE comment, which clients ignore but which keeps
+// proxies from closing an idle connection.
+func WriteHeartbeat(w io.Writer) error {
+	_, err := io.WriteString(w, ": heartbeat\n\n")
+	return err
+}
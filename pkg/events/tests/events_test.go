@@ -0,0 +1,47 @@
+package events_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/events"
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareIDs(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1700000000000-0", "1700000000000-0", 0},
+		{"1700000000000-1", "1700000000000-0", 1},
+		// Сравнение числовое, а не строковое
+		{"999-0", "1000-0", -1},
+		{"1000-2", "1000-10", -1},
+	}
+	for _, tc := range cases {
+		got, err := events.CompareIDs(tc.a, tc.b)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, got, tc.a+" vs "+tc.b)
+	}
+
+	_, err := events.CompareIDs("abc", "1-0")
+	assert.ErrorIs(t, err, events.ErrInvalidEventID)
+}
+
+func TestWriteSSE(t *testing.T) {
+	var b strings.Builder
+
+	err := events.WriteSSE(&b, models.Event{ID: "5-0", Type: models.EventBookUpdated, Data: json.RawMessage(`{"id":1}`)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 5-0\nevent: book_updated\ndata: {\"id\":1}\n\n", b.String())
+
+	// Многострочные данные разбиваются на несколько полей data
+	b.Reset()
+	assert.NoError(t, events.WriteSSE(&b, models.Event{Data: json.RawMessage("a\nb")}))
+	assert.Equal(t, "data: a\ndata: b\n\n", b.String())
+}
@@ -0,0 +1,183 @@
+// Package fixtures loads curated datasets of users, authors and books into
+// the database through the services, so the rows look like ones made through
+// the API. Datasets are YAML or JSON; the ones shipped with the module are
+// embedded and addressed by name, e.g. "demo".
+package fixtures
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed data/*.yaml
+var embedded embed.FS
+
+// ErrUnknownDataset is returned by Open for a name that is neither a file
+// nor an embedded dataset.
+var ErrUnknownDataset = errors.New("fixtures: unknown dataset")
+
+// Dataset is the file format. Users come first so books can refer to their
+// authors by username; both lists are loaded in file order.
+type Dataset struct {
+	Users []User `yaml:"users" json:"users"`
+	Books []Book `yaml:"books" json:"books"`
+}
+
+type User struct {
+	Username string  `yaml:"username" json:"username"`
+	Password string  `yaml:"password" json:"password"`
+	Admin    bool    `yaml:"admin" json:"admin"`
+	Author   *Author `yaml:"author" json:"author"`
+}
+
+// Author is the author profile of the enclosing user.
+type Author struct {
+	Firstname string   `yaml:"firstname" json:"firstname"`
+	Lastname  string   `yaml:"lastname" json:"lastname"`
+	Birthday  string   `yaml:"birthday" json:"birthday"`
+	Bio       string   `yaml:"bio" json:"bio"`
+	Country   string   `yaml:"country" json:"country"`
+	Website   string   `yaml:"website" json:"website"`
+	PenNames  []string `yaml:"pen_names" json:"pen_names"`
+}
+
+// Book is published by the user named in Author, who must have an author
+// profile in the same dataset.
+type Book struct {
+	Author   string `yaml:"author" json:"author"`
+	Title    string `yaml:"title" json:"title"`
+	Language string `yaml:"language" json:"language"`
+	Content  string `yaml:"content" json:"content"`
+}
+
+// Names lists the embedded datasets.
+func Names() []string {
+	entries, _ := fs.ReadDir(embedded, "data")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open reads a dataset from a .yaml, .yml or .json file, or an embedded
+// dataset when name has no such extension.
+func Open(name string) (*Dataset, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return Parse(name, data)
+	}
+	data, err := embedded.ReadFile(path.Join("data", name+".yaml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w %q, have %s", ErrUnknownDataset, name, strings.Join(Names(), ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(name+".yaml", data)
+}
+
+// Parse decodes data as JSON when name ends in .json and as YAML otherwise,
+// rejecting unknown fields, and validates the result.
+func Parse(name string, data []byte) (*Dataset, error) {
+	var ds Dataset
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&ds); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&ds); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if err := ds.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &ds, nil
+}
+
+// Validate checks what the services would otherwise reject halfway through
+// a load: duplicate keys, short credentials and books without an author.
+func (ds *Dataset) Validate() error {
+	var errs []error
+	usernames := make(map[string]bool, len(ds.Users))
+	authors := make(map[string]bool, len(ds.Users))
+	for i, u := range ds.Users {
+		switch {
+		case len(u.Username) < 5:
+			errs = append(errs, fmt.Errorf("users[%d]: username must be at least 5 characters", i))
+		case usernames[u.Username]:
+			errs = append(errs, fmt.Errorf("users[%d]: duplicate username %q", i, u.Username))
+		}
+		usernames[u.Username] = true
+		if len(u.Password) < 8 {
+			errs = append(errs, fmt.Errorf("users[%d]: password must be at least 8 characters", i))
+		}
+		if u.Author != nil {
+			if _, err := u.Author.model(0); err != nil {
+				errs = append(errs, fmt.Errorf("users[%d].author: %w", i, err))
+			}
+			authors[u.Username] = true
+		}
+	}
+	titles := make(map[string]bool, len(ds.Books))
+	for i, b := range ds.Books {
+		if !authors[b.Author] {
+			errs = append(errs, fmt.Errorf("books[%d]: %q has no author profile in this dataset", i, b.Author))
+		}
+		if n := len(b.Title); n < 3 || n > 400 {
+			errs = append(errs, fmt.Errorf("books[%d]: title must be between 3 and 400 characters", i))
+		} else if titles[b.Title] {
+			errs = append(errs, fmt.Errorf("books[%d]: duplicate title %q", i, b.Title))
+		}
+		titles[b.Title] = true
+		if len(strings.TrimSpace(b.Content)) < 10 {
+			errs = append(errs, fmt.Errorf("books[%d]: content must be at least 10 characters", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Author) model(userID uint) (*models.Author, error) {
+	if a.Firstname == "" || a.Lastname == "" {
+		return nil, errors.New("firstname and lastname are required")
+	}
+	birthday, err := time.Parse("2006-01-02", a.Birthday)
+	if err != nil {
+		return nil, fmt.Errorf("birthday must be YYYY-MM-DD: %w", err)
+	}
+	return &models.Author{
+		UserID:    userID,
+		Firstname: a.Firstname,
+		Lastname:  a.Lastname,
+		Birthday:  models.DateOnly{Time: birthday},
+		Bio:       a.Bio,
+		Country:   a.Country,
+		Website:   a.Website,
+		PenNames:  models.StringList(a.PenNames),
+	}, nil
+}
@@ -0,0 +1,147 @@
+package fixtures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"gorm.io/gorm"
+)
+
+// Loader writes datasets through the services, so passwords are hashed,
+// text statistics computed and caches invalidated exactly as for API calls.
+// The repositories are only read, to skip what already exists: loading the
+// same dataset twice changes nothing.
+type Loader struct {
+	users      services.UserService
+	userRepo   repositories.UserRepo
+	authors    services.AuthorService
+	authorRepo repositories.AuthorRepo
+	books      services.BookService
+	bookRepo   repositories.BookRepo
+}
+
+// Result counts the rows a Load created.
+type Result struct {
+	Users   int
+	Authors int
+	Books   int
+}
+
+func NewLoader(users services.UserService, userRepo repositories.UserRepo, authors services.AuthorService, authorRepo repositories.AuthorRepo, books services.BookService, bookRepo repositories.BookRepo) *Loader {
+	return &Loader{
+		users:      users,
+		userRepo:   userRepo,
+		authors:    authors,
+		authorRepo: authorRepo,
+		books:      books,
+		bookRepo:   bookRepo,
+	}
+}
+
+// Load creates the users, author profiles and books of ds in file order.
+// Existing usernames, profiles and titles are left untouched, apart from
+// granting admin rights the dataset asks for. It stops at the first error;
+// what was created until then stays, and a second run picks up the rest.
+func (l *Loader) Load(ctx context.Context, ds *Dataset) (Result, error) {
+	var res Result
+	ids := make(map[string]uint, len(ds.Users))
+	for _, u := range ds.Users {
+		user, created, err := l.user(ctx, u)
+		if err != nil {
+			return res, fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		if created {
+			res.Users++
+		}
+		if u.Admin && !user.IsAdmin {
+			if err := l.users.SetAdmin(ctx, user.ID, true); err != nil {
+				return res, fmt.Errorf("promote %s: %w", u.Username, err)
+			}
+		}
+		ids[u.Username] = user.ID
+		if u.Author == nil {
+			continue
+		}
+		created, err = l.author(ctx, user.ID, u.Author)
+		if err != nil {
+			return res, fmt.Errorf("author %s: %w", u.Username, err)
+		}
+		if created {
+			res.Authors++
+		}
+	}
+
+	for _, b := range ds.Books {
+		created, err := l.book(ctx, ids[b.Author], b)
+		if err != nil {
+			return res, fmt.Errorf("book %q: %w", b.Title, err)
+		}
+		if created {
+			res.Books++
+		}
+	}
+	return res, nil
+}
+
+func (l *Loader) user(ctx context.Context, u User) (*models.UserDB, bool, error) {
+	user, err := l.userRepo.GetByUsername(ctx, u.Username)
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	// CreateUser wipes the password it is given.
+	if err := l.users.CreateUser(ctx, u.Username, []byte(u.Password)); err != nil {
+		return nil, false, err
+	}
+	user, err = l.userRepo.GetByUsername(ctx, u.Username)
+	return user, err == nil, err
+}
+
+func (l *Loader) author(ctx context.Context, userID uint, a *Author) (bool, error) {
+	_, err := l.authorRepo.GetByID(ctx, userID)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	author, err := a.model(userID)
+	if err != nil {
+		return false, err
+	}
+	return true, l.authors.CreateAuthor(ctx, author)
+}
+
+// titlesPage bounds each listing while looking for existing titles.
+const titlesPage = 100
+
+func (l *Loader) book(ctx context.Context, authorID uint, b Book) (bool, error) {
+	for page := uint(1); ; page++ {
+		p, err := l.bookRepo.GetByAuthor(ctx, authorID, &models.Pagination{Limit: titlesPage, Page: page, Sort: "id"})
+		if err != nil {
+			return false, err
+		}
+		books, _ := p.Rows.([]models.Book)
+		for _, existing := range books {
+			if existing.Title == b.Title {
+				return false, nil
+			}
+		}
+		if page >= p.TotalPages {
+			break
+		}
+	}
+	return true, l.books.CreateBook(ctx, &models.Book{
+		AuthorID: authorID,
+		Title:    b.Title,
+		Language: b.Language,
+		Content:  b.Content,
+	})
+}
@@ -0,0 +1,263 @@
+package fixtures_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/fixtures"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const datasetYAML = `
+users:
+  - username: admin_user
+    password: secret-password
+    admin: true
+  - username: writer_one
+    password: secret-password
+    author:
+      firstname: Jane
+      lastname: Austen
+      birthday: "1775-12-16"
+      pen_names: [A Lady]
+books:
+  - author: writer_one
+    title: Pride and Prejudice
+    language: en
+    content: It is a truth universally acknowledged.
+  - author: writer_one
+    title: Emma
+    content: Emma Woodhouse, handsome, clever, and rich.
+`
+
+const datasetJSON = `{
+  "users": [
+    {"username": "admin_user", "password": "secret-password", "admin": true},
+    {"username": "writer_one", "password": "secret-password", "author": {
+      "firstname": "Jane", "lastname": "Austen", "birthday": "1775-12-16", "pen_names": ["A Lady"]}}
+  ],
+  "books": [
+    {"author": "writer_one", "title": "Pride and Prejudice", "language": "en", "content": "It is a truth universally acknowledged."},
+    {"author": "writer_one", "title": "Emma", "content": "Emma Woodhouse, handsome, clever, and rich."}
+  ]
+}`
+
+func TestParse_YAMLAndJSON(t *testing.T) {
+	fromYAML, err := fixtures.Parse("set.yaml", []byte(datasetYAML))
+	require.NoError(t, err)
+	fromJSON, err := fixtures.Parse("set.json", []byte(datasetJSON))
+	require.NoError(t, err)
+
+	// Оба формата описывают одно и то же
+	assert.Equal(t, fromYAML, fromJSON)
+	assert.Len(t, fromYAML.Users, 2)
+	assert.Equal(t, []string{"A Lady"}, fromYAML.Users[1].Author.PenNames)
+
+	// Неизвестные поля считаются опечаткой
+	_, err = fixtures.Parse("set.yaml", []byte("users:\n  - username: admin_user\n    pasword: secret-password\n"))
+	assert.Error(t, err)
+	_, err = fixtures.Parse("set.json", []byte(`{"user": []}`))
+	assert.Error(t, err)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := fixtures.Parse("set.yaml", []byte(`
+users:
+  - username: short
+    password: short
+  - username: short
+    password: secret-password
+    author:
+      firstname: Jane
+      lastname: Austen
+      birthday: 16.12.1775
+books:
+  - author: nobody_here
+    title: Emma
+    content: Emma Woodhouse, handsome, clever, and rich.
+  - author: short
+    title: Emma
+    content: tiny
+`))
+	require.Error(t, err)
+
+	// Все ошибки собираются сразу, а не по одной
+	for _, want := range []string{
+		"users[0]: password",
+		"users[1]: duplicate username",
+		"users[1].author: birthday",
+		`books[0]: "nobody_here" has no author profile`,
+		`books[1]: duplicate title "Emma"`,
+		"books[1]: content",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestOpen_Embedded(t *testing.T) {
+	assert.Contains(t, fixtures.Names(), "demo")
+
+	// Встроенный набор должен проходить проверку
+	demo, err := fixtures.Open("demo")
+	require.NoError(t, err)
+	assert.NotEmpty(t, demo.Users)
+	assert.NotEmpty(t, demo.Books)
+
+	_, err = fixtures.Open("missing")
+	assert.ErrorIs(t, err, fixtures.ErrUnknownDataset)
+}
+
+func TestLoader_Idempotent(t *testing.T) {
+	ds, err := fixtures.Parse("set.yaml", []byte(datasetYAML))
+	require.NoError(t, err)
+	db := newMemory()
+	loader := db.loader()
+
+	res, err := loader.Load(context.Background(), ds)
+	require.NoError(t, err)
+	assert.Equal(t, fixtures.Result{Users: 2, Authors: 1, Books: 2}, res)
+
+	// Идентификаторы зависят только от порядка в файле
+	assert.Equal(t, uint(1), db.users["admin_user"].ID)
+	assert.True(t, db.users["admin_user"].IsAdmin)
+	require.Contains(t, db.authors, uint(2))
+	assert.Equal(t, "1775-12-16", db.authors[2].Birthday.Format("2006-01-02"))
+	require.Len(t, db.books, 2)
+	assert.Equal(t, "Pride and Prejudice", db.books[0].Title)
+	assert.Equal(t, uint(2), db.books[0].AuthorID)
+
+	// Повторная загрузка ничего не меняет
+	res, err = loader.Load(context.Background(), ds)
+	require.NoError(t, err)
+	assert.Equal(t, fixtures.Result{}, res)
+	assert.Len(t, db.books, 2)
+}
+
+func TestLoader_StopsOnError(t *testing.T) {
+	ds, err := fixtures.Parse("set.yaml", []byte(datasetYAML))
+	require.NoError(t, err)
+	db := newMemory()
+	db.failTitle = "Emma"
+
+	res, err := db.loader().Load(context.Background(), ds)
+	assert.ErrorContains(t, err, `book "Emma"`)
+	assert.Equal(t, fixtures.Result{Users: 2, Authors: 1, Books: 1}, res)
+
+	// Вторая попытка дозагружает недостающее
+	db.failTitle = ""
+	res, err = db.loader().Load(context.Background(), ds)
+	require.NoError(t, err)
+	assert.Equal(t, fixtures.Result{Books: 1}, res)
+}
+
+// memory stands in for the database behind the services and repositories
+// the loader uses; everything else panics through the nil interfaces.
+type memory struct {
+	users     map[string]*models.UserDB
+	authors   map[uint]*models.Author
+	books     []models.Book
+	failTitle string
+}
+
+func newMemory() *memory {
+	return &memory{users: map[string]*models.UserDB{}, authors: map[uint]*models.Author{}}
+}
+
+func (m *memory) loader() *fixtures.Loader {
+	return fixtures.NewLoader(userService{m: m}, userRepo{m: m}, authorService{m: m}, authorRepo{m: m}, bookService{m: m}, bookRepo{m: m})
+}
+
+type userService struct {
+	services.UserService
+	m *memory
+}
+
+func (s userService) CreateUser(ctx context.Context, username string, pwd []byte) error {
+	user := &models.UserDB{Username: username, PasswordHash: pwd}
+	user.ID = uint(len(s.m.users) + 1)
+	s.m.users[username] = user
+	return nil
+}
+
+func (s userService) SetAdmin(ctx context.Context, id uint, admin bool) error {
+	for _, u := range s.m.users {
+		if u.ID == id {
+			u.IsAdmin = admin
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+type userRepo struct {
+	repositories.UserRepo
+	m *memory
+}
+
+func (r userRepo) GetByUsername(ctx context.Context, username string) (*models.UserDB, error) {
+	if u, ok := r.m.users[username]; ok {
+		return u, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type authorService struct {
+	services.AuthorService
+	m *memory
+}
+
+func (s authorService) CreateAuthor(ctx context.Context, author *models.Author) error {
+	s.m.authors[author.UserID] = author
+	return nil
+}
+
+type authorRepo struct {
+	repositories.AuthorRepo
+	m *memory
+}
+
+func (r authorRepo) GetByID(ctx context.Context, id uint) (*models.Author, error) {
+	if a, ok := r.m.authors[id]; ok {
+		return a, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type bookService struct {
+	services.BookService
+	m *memory
+}
+
+func (s bookService) CreateBook(ctx context.Context, book *models.Book) error {
+	if book.Title == s.m.failTitle {
+		return errors.New("database error")
+	}
+	book.ID = uint(len(s.m.books) + 1)
+	s.m.books = append(s.m.books, *book)
+	return nil
+}
+
+type bookRepo struct {
+	repositories.BookRepo
+	m *memory
+}
+
+func (r bookRepo) GetByAuthor(ctx context.Context, authorID uint, p *models.Pagination) (*models.Pagination, error) {
+	var books []models.Book
+	for _, b := range r.m.books {
+		if b.AuthorID == authorID {
+			books = append(books, b)
+		}
+	}
+	p.Rows = books
+	p.TotalRows = uint64(len(books))
+	p.TotalPages = 1
+	return p, nil
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// checkTimeout bounds each check, so one hung dependency cannot stall the
+// probe past the orchestrator's own timeout.
+const checkTimeout = 2 * time.Second
+
+var ErrDraining = errors.New("server is shutting down")
+
+// Check returns nil when the dependency is usable.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker runs the readiness checks. Once draining, it reports down without
+// running them, so load balancers stop routing before connections close.
+type Checker struct {
+	mu       sync.RWMutex
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]Check)}
+}
+
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+func (c *Checker) SetDraining() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Ready runs every check concurrently.
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult)}
+	if c.Draining() {
+		report.Status = StatusDown
+		report.Checks["shutdown"] = CheckResult{Status: StatusDown, Error: ErrDraining.Error()}
+		return report
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	started := time.Now()
+	err := check(ctx)
+	result := CheckResult{Status: StatusUp, LatencyMs: time.Since(started).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
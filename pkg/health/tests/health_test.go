@@ -0,0 +1,45 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/health"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckerReady(t *testing.T) {
+	c := health.NewChecker()
+	c.Register("postgres", func(ctx context.Context) error { return nil })
+	c.Register("redis", func(ctx context.Context) error { return nil })
+
+	report := c.Ready(context.Background())
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, health.StatusUp, report.Checks["redis"].Status)
+
+	// Одна упавшая зависимость делает весь сервис неготовым
+	c.Register("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+	report = c.Ready(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, health.StatusUp, report.Checks["postgres"].Status)
+	assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+}
+
+func TestCheckerDraining(t *testing.T) {
+	called := false
+	c := health.NewChecker()
+	c.Register("postgres", func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	c.SetDraining()
+
+	// Во время остановки проверки не запускаются
+	report := c.Ready(context.Background())
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, health.ErrDraining.Error(), report.Checks["shutdown"].Error)
+	assert.False(t, called)
+}
@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+// MemoryQueue keeps jobs in the process. It is the fallback when Redis is
+// unavailable: jobs are lost on restart and not shared between instances.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	jobs     map[string]models.Job
+	inflight map[string]time.Time
+	dead     []string
+}
+
+var _ Queue = (*MemoryQueue)(nil)
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		jobs:     make(map[string]models.Job),
+		inflight: make(map[string]time.Time),
+	}
+}
+
+func (q *MemoryQueue) Enqueue(ctx context.Context, job *models.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs[job.ID] = *job
+	return nil
+}
+
+func (q *MemoryQueue) Dequeue(ctx context.Context, visibility time.Duration) (*models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now().UTC()
+	var next *models.Job
+	for id, j := range q.jobs {
+		ready := (j.Status == models.JobQueued || j.Status == models.JobFailed) && !j.RunAfter.After(now)
+		if deadline, ok := q.inflight[id]; ok {
+			ready = now.After(deadline)
+		}
+		if ready && (next == nil || j.RunAfter.Before(next.RunAfter)) {
+			j := j
+			next = &j
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.Attempts++
+	next.UpdatedAt = now
+	if next.Attempts > next.MaxAttempts {
+		// Its last worker timed out without reporting back.
+		next.Status = models.JobDead
+		delete(q.inflight, next.ID)
+		q.dead = append(q.dead, next.ID)
+		q.jobs[next.ID] = *next
+		return nil, nil
+	}
+	next.Status = models.JobRunning
+	q.inflight[next.ID] = now.Add(visibility)
+	q.jobs[next.ID] = *next
+	job := *next
+	return &job, nil
+}
+
+func (q *MemoryQueue) Ack(ctx context.Context, job *models.Job, result any) error {
+	if err := complete(job, result); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inflight, job.ID)
+	q.jobs[job.ID] = *job
+	return nil
+}
+
+func (q *MemoryQueue) Nack(ctx context.Context, job *models.Job, cause error, delay time.Duration) error {
+	dead := fail(job, cause, delay)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inflight, job.ID)
+	if dead {
+		q.dead = append(q.dead, job.ID)
+	}
+	q.jobs[job.ID] = *job
+	return nil
+}
+
+func (q *MemoryQueue) Get(ctx context.Context, id string) (*models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return &j, nil
+}
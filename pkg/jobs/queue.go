@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	errPermanent   = errors.New("permanent failure")
+)
+
+const DefaultMaxAttempts = 5
+
+// Queue hands each job to one worker at a time. A dequeued job is invisible
+// to other workers until it is acked, nacked or its visibility timeout
+// passes, after which it is handed out again; so a worker that dies mid-job
+// does not lose it.
+type Queue interface {
+	Enqueue(ctx context.Context, job *models.Job) error
+	// Dequeue returns nil, nil when no job is ready.
+	Dequeue(ctx context.Context, visibility time.Duration) (*models.Job, error)
+	Ack(ctx context.Context, job *models.Job, result any) error
+	// Nack schedules a retry after delay, or dead-letters the job when it
+	// has used all its attempts or cause is Permanent.
+	Nack(ctx context.Context, job *models.Job, cause error, delay time.Duration) error
+	Get(ctx context.Context, id string) (*models.Job, error)
+}
+
+// New builds a queued job; the payload is stored as JSON.
+func New(jobType string, ownerID uint, payload any) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	return &models.Job{
+		ID:          id,
+		Type:        jobType,
+		OwnerID:     ownerID,
+		Payload:     data,
+		Status:      models.JobQueued,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAfter:    now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Permanent marks a handler error that retrying cannot fix, such as a
+// malformed upload; the job is dead-lettered right away.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", errPermanent, err)
+}
+
+// Backoff is the delay before a failed job runs again: 10s, 20s, 40s...
+// capped at 30 minutes.
+func Backoff(attempts uint) time.Duration {
+	d := 10 * time.Second
+	for i := uint(1); i < attempts && d < 30*time.Minute; i++ {
+		d *= 2
+	}
+	return min(d, 30*time.Minute)
+}
+
+func complete(job *models.Job, result any) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	job.Status = models.JobSucceeded
+	job.Result = data
+	job.Error = ""
+	job.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// fail reports whether the job is dead.
+func fail(job *models.Job, cause error, delay time.Duration) bool {
+	job.Error = cause.Error()
+	job.UpdatedAt = time.Now().UTC()
+	if job.Attempts >= job.MaxAttempts || errors.Is(cause, errPermanent) {
+		job.Status = models.JobDead
+		return true
+	}
+	job.Status = models.JobFailed
+	job.RunAfter = job.UpdatedAt.Add(delay)
+	return false
+}
@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	readyKey    = "jobs:ready"
+	inflightKey = "jobs:inflight"
+	deadKey     = "jobs:dead"
+	// finishedTTL is how long finished jobs stay readable by GET /jobs/:id.
+	finishedTTL = 7 * 24 * time.Hour
+)
+
+// claimScript returns jobs whose visibility timeout passed to the ready set,
+// then moves the oldest ready job into the in-flight set. Both sets are
+// scored in unix milliseconds.
+var claimScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[1], ARGV[1], id)
+end
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+redis.call('ZREM', KEYS[1], ids[1])
+redis.call('ZADD', KEYS[2], ARGV[2], ids[1])
+return ids[1]
+`)
+
+// RedisQueue keeps each job as JSON under jobs:job:<id>, with sorted sets for
+// ready and in-flight jobs and a list of dead-lettered ids.
+type RedisQueue struct {
+	client *redis.Client
+}
+
+var _ Queue = (*RedisQueue)(nil)
+
+func NewRedisQueue(client *redis.Client) *RedisQueue {
+	return &RedisQueue{client: client}
+}
+
+func (q *RedisQueue) Enqueue(ctx context.Context, job *models.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, jobKey(job.ID), data, 0)
+		p.ZAdd(ctx, readyKey, redis.Z{Score: float64(job.RunAfter.UnixMilli()), Member: job.ID})
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) Dequeue(ctx context.Context, visibility time.Duration) (*models.Job, error) {
+	now := time.Now().UTC()
+	id, err := claimScript.Run(ctx, q.client, []string{readyKey, inflightKey}, now.UnixMilli(), now.Add(visibility).UnixMilli()).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job, err := q.Get(ctx, id)
+	if errors.Is(err, ErrJobNotFound) {
+		q.client.ZRem(ctx, inflightKey, id)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.Attempts++
+	job.UpdatedAt = now
+	if job.Attempts > job.MaxAttempts {
+		// Its last worker timed out without reporting back.
+		job.Status = models.JobDead
+		return nil, q.bury(ctx, job)
+	}
+	job.Status = models.JobRunning
+	return job, q.save(ctx, job, 0)
+}
+
+func (q *RedisQueue) Ack(ctx context.Context, job *models.Job, result any) error {
+	if err := complete(job, result); err != nil {
+		return err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZRem(ctx, inflightKey, job.ID)
+		p.Set(ctx, jobKey(job.ID), data, finishedTTL)
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) Nack(ctx context.Context, job *models.Job, cause error, delay time.Duration) error {
+	if fail(job, cause, delay) {
+		return q.bury(ctx, job)
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZRem(ctx, inflightKey, job.ID)
+		p.ZAdd(ctx, readyKey, redis.Z{Score: float64(job.RunAfter.UnixMilli()), Member: job.ID})
+		p.Set(ctx, jobKey(job.ID), data, 0)
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) Get(ctx context.Context, id string) (*models.Job, error) {
+	data, err := q.client.Get(ctx, jobKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job models.Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// bury moves a job to the dead-letter list; its record stays readable for
+// finishedTTL.
+func (q *RedisQueue) bury(ctx context.Context, job *models.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZRem(ctx, inflightKey, job.ID)
+		p.RPush(ctx, deadKey, job.ID)
+		p.Set(ctx, jobKey(job.ID), data, finishedTTL)
+		return nil
+	})
+	return err
+}
+
+func (q *RedisQueue) save(ctx context.Context, job *models.Job, ttl time.Duration) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.client.Set(ctx, jobKey(job.ID), data, ttl).Err()
+}
+
+func jobKey(id string) string {
+	return "jobs:job:" + id
+}
@@ -0,0 +1,202 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/jobs"
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, jobs.Backoff(1))
+	assert.Equal(t, 20*time.Second, jobs.Backoff(2))
+	assert.Equal(t, 80*time.Second, jobs.Backoff(4))
+	// Задержка не растёт бесконечно
+	assert.Equal(t, 30*time.Minute, jobs.Backoff(50))
+}
+
+func TestMemoryQueueAck(t *testing.T) {
+	ctx := context.Background()
+	q := jobs.NewMemoryQueue()
+	job, err := jobs.New(models.JobBookExport, 7, models.ExportJob{AuthorID: 7, Format: "txt"})
+	require.NoError(t, err)
+	require.NoError(t, q.Enqueue(ctx, job))
+
+	got, err := q.Dequeue(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, job.ID, got.ID)
+	assert.Equal(t, models.JobRunning, got.Status)
+	assert.Equal(t, uint(1), got.Attempts)
+
+	// Пока задача в работе, другим воркерам она не видна
+	none, err := q.Dequeue(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	require.NoError(t, q.Ack(ctx, got, models.ExportResult{URL: "/media/x.zip", Books: 2}))
+	stored, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobSucceeded, stored.Status)
+	assert.JSONEq(t, `{"url":"/media/x.zip","books":2}`, string(stored.Result))
+
+	_, err = q.Get(ctx, "missing")
+	assert.ErrorIs(t, err, jobs.ErrJobNotFound)
+}
+
+func TestMemoryQueueRetryAndDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	q := jobs.NewMemoryQueue()
+	job, err := jobs.New(models.JobBookImport, 1, models.ImportJob{BlobKey: "imports/1/a.epub"})
+	require.NoError(t, err)
+	job.MaxAttempts = 2
+	require.NoError(t, q.Enqueue(ctx, job))
+
+	got, err := q.Dequeue(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, q.Nack(ctx, got, errors.New("boom"), time.Hour))
+	stored, _ := q.Get(ctx, job.ID)
+	assert.Equal(t, models.JobFailed, stored.Status)
+	assert.Equal(t, "boom", stored.Error)
+
+	// Повтор ещё не наступил
+	none, err := q.Dequeue(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	require.NoError(t, q.Nack(ctx, got, errors.New("boom"), 0))
+	got, err = q.Dequeue(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, uint(2), got.Attempts)
+
+	// Попытки кончились: задача уходит в dead letter
+	require.NoError(t, q.Nack(ctx, got, errors.New("boom again"), 0))
+	stored, _ = q.Get(ctx, job.ID)
+	assert.Equal(t, models.JobDead, stored.Status)
+	none, err = q.Dequeue(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+}
+
+func TestMemoryQueueVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	q := jobs.NewMemoryQueue()
+	job, err := jobs.New(models.JobBookExport, 1, models.ExportJob{})
+	require.NoError(t, err)
+	job.MaxAttempts = 2
+	require.NoError(t, q.Enqueue(ctx, job))
+
+	_, err = q.Dequeue(ctx, time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	// Воркер пропал, задача снова доступна
+	got, err := q.Dequeue(ctx, time.Millisecond)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, uint(2), got.Attempts)
+	time.Sleep(5 * time.Millisecond)
+
+	none, err := q.Dequeue(ctx, time.Millisecond)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+	stored, _ := q.Get(ctx, job.ID)
+	assert.Equal(t, models.JobDead, stored.Status)
+}
+
+func TestPoolRunsTypedHandlers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := jobs.NewMemoryQueue()
+	pool := jobs.NewPool(q, 2, time.Minute)
+
+	var calls atomic.Int32
+	var seen atomic.Value
+	pool.Register(models.JobBookExport, jobs.Typed(func(ctx context.Context, ownerID uint, job models.ExportJob) (any, error) {
+		calls.Add(1)
+		seen.Store(jobs.ID(ctx))
+		return models.ExportResult{Books: len(job.BookIDs)}, nil
+	}))
+	pool.Register(models.JobBookImport, jobs.Typed(func(ctx context.Context, ownerID uint, job models.ImportJob) (any, error) {
+		return nil, jobs.Permanent(errors.New("not an epub"))
+	}))
+
+	ok, _ := jobs.New(models.JobBookExport, 1, models.ExportJob{BookIDs: []uint{1, 2, 3}})
+	bad, _ := jobs.New(models.JobBookImport, 1, models.ImportJob{})
+	require.NoError(t, q.Enqueue(ctx, ok))
+	require.NoError(t, q.Enqueue(ctx, bad))
+
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		a, _ := q.Get(ctx, ok.ID)
+		b, _ := q.Get(ctx, bad.ID)
+		return a.Status == models.JobSucceeded && b.Status == models.JobDead
+	}, 3*time.Second, 10*time.Millisecond)
+
+	a, _ := q.Get(ctx, ok.ID)
+	assert.JSONEq(t, `{"url":"","books":3}`, string(a.Result))
+	// Неисправимая ошибка не повторяется
+	b, _ := q.Get(ctx, bad.ID)
+	assert.Equal(t, uint(1), b.Attempts)
+	assert.Contains(t, b.Error, "not an epub")
+	assert.Equal(t, int32(1), calls.Load())
+	// Обработчик знает, какую задачу выполняет
+	assert.Equal(t, ok.ID, seen.Load())
+	assert.Empty(t, jobs.ID(ctx))
+
+	cancel()
+	<-done
+}
+
+func TestPoolAbortCancelsRunningJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := jobs.NewMemoryQueue()
+	pool := jobs.NewPool(q, 1, time.Hour)
+
+	started := make(chan struct{})
+	pool.Register(models.JobBookExport, jobs.Typed(func(ctx context.Context, ownerID uint, job models.ExportJob) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+	job, _ := jobs.New(models.JobBookExport, 1, models.ExportJob{})
+	require.NoError(t, q.Enqueue(ctx, job))
+
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(done)
+	}()
+	<-started
+
+	// Остановка пула не прерывает уже запущенную задачу
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("Run returned while a job was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pool.Abort()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Abort did not cancel the running job")
+	}
+	stored, _ := q.Get(context.Background(), job.ID)
+	assert.Equal(t, models.JobFailed, stored.Status)
+	assert.Contains(t, stored.Error, "context canceled")
+}
@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+const pollInterval = 500 * time.Millisecond
+
+// Handler runs one job and returns its result, which is stored as JSON.
+type Handler func(ctx context.Context, job *models.Job) (any, error)
+
+type jobIDKey struct{}
+
+// ID returns the id of the job a handler's ctx belongs to, or "" outside of
+// a handler. A job may run more than once, so handlers with side effects use
+// it to recognise a repeated run.
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(jobIDKey{}).(string)
+	return id
+}
+
+// Typed adapts a handler that takes a decoded payload.
+func Typed[T any](fn func(ctx context.Context, ownerID uint, payload T) (any, error)) Handler {
+	return func(ctx context.Context, job *models.Job) (any, error) {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("decode %s payload: %w", job.Type, err)
+		}
+		return fn(ctx, job.OwnerID, payload)
+	}
+}
+
+// Pool runs jobs with a fixed number of workers. A handler gets the
+// visibility timeout as its deadline, so it is cancelled before another
+// worker may pick the same job up.
+type Pool struct {
+	queue       Queue
+	handlers    map[string]Handler
+	concurrency int
+	visibility  time.Duration
+	running     atomic.Int32
+	// aborted is cancelled by Abort and cancels every running handler.
+	aborted context.Context
+	abort   context.CancelFunc
+}
+
+func NewPool(queue Queue, concurrency int, visibility time.Duration) *Pool {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	if visibility <= 0 {
+		visibility = 5 * time.Minute
+	}
+	aborted, abort := context.WithCancel(context.Background())
+	return &Pool{queue: queue, handlers: make(map[string]Handler), concurrency: concurrency, visibility: visibility, aborted: aborted, abort: abort}
+}
+
+// Register must be called before Run.
+func (p *Pool) Register(jobType string, handler Handler) {
+	p.handlers[jobType] = handler
+}
+
+// Run blocks until ctx is cancelled and every running job has returned.
+// Cancelling ctx only stops workers from taking new jobs: a running handler
+// keeps its own context, which ends with the visibility timeout or Abort.
+// Callers with a shorter deadline than the visibility timeout must call
+// Abort when it is reached, or Run does not return in time.
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.running.Add(1)
+			defer p.running.Add(-1)
+			p.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Abort cancels the context of every running and future handler. The jobs
+// are nacked as failed attempts, or, if the queue is gone by then, run
+// again once their visibility timeout has passed.
+func (p *Pool) Abort() {
+	p.abort()
+}
+
+// Healthy reports whether every worker is polling.
+func (p *Pool) Healthy() error {
+	if n := int(p.running.Load()); n < p.concurrency {
+		return fmt.Errorf("%d of %d job workers running", n, p.concurrency)
+	}
+	return nil
+}
+
+func (p *Pool) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := p.queue.Dequeue(ctx, p.visibility)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Job worker error, dequeue", "error", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		p.process(ctx, job)
+	}
+}
+
+func (p *Pool) process(ctx context.Context, job *models.Job) {
+	report, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		if err := p.queue.Nack(report, job, Permanent(fmt.Errorf("no handler for job type %q", job.Type)), 0); err != nil {
+			slog.ErrorContext(ctx, "Job worker error, nack job", "job_id", job.ID, "error", err)
+		}
+		return
+	}
+
+	result, err := p.run(ctx, handler, job)
+	if err != nil {
+		slog.WarnContext(ctx, "Job worker: job attempt failed", "job_id", job.ID, "job_type", job.Type, "attempt", job.Attempts, "error", err)
+		err = p.queue.Nack(report, job, err, Backoff(job.Attempts))
+	} else {
+		err = p.queue.Ack(report, job, result)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Job worker error, report job", "job_id", job.ID, "error", err)
+	}
+}
+
+func (p *Pool) run(ctx context.Context, handler Handler, job *models.Job) (result any, err error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.visibility)
+	defer cancel()
+	defer context.AfterFunc(p.aborted, cancel)()
+	ctx = context.WithValue(ctx, jobIDKey{}, job.ID)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger sends GORM output through slog. Statements are logged at debug,
+// slow ones at warn and failed ones at error; a missing record is not a
+// failure.
+type GormLogger struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+}
+
+var _ logger.Interface = GormLogger{}
+
+func (l GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	// The level is set on the slog handler instead.
+	return l
+}
+
+func (l GormLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.Logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l GormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.Logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l GormLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.Logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level = slog.LevelError
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		level = slog.LevelWarn
+	}
+	if !l.Logger.Enabled(ctx, level) {
+		return
+	}
+	sql, rows := fc()
+	attrs := []any{"sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds()}
+	if level == slog.LevelError {
+		attrs = append(attrs, "error", err)
+	}
+	l.Logger.Log(ctx, level, "Database query", attrs...)
+}
@@ -0,0 +1,71 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/logging"
+	"github.com/Quavke/eBookReader/pkg/middlewares"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, true, "info")
+
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "Book controller GetByID error", "error", "boom")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "boom", record["error"])
+
+	// Ниже заданного уровня ничего не пишется
+	buf.Reset()
+	logger.DebugContext(ctx, "Cached book data")
+	assert.Empty(t, buf.String())
+}
+
+func TestTextLoggerInDev(t *testing.T) {
+	var buf bytes.Buffer
+	logging.New(&buf, false, "debug").Debug("Cached book data")
+	assert.True(t, strings.Contains(buf.String(), `msg="Cached book data"`))
+	assert.Equal(t, slog.LevelWarn, logging.ParseLevel("WARN"))
+	assert.Equal(t, slog.LevelInfo, logging.ParseLevel(""))
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.RequestIDMiddleware())
+	var seen string
+	router.GET("/", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+	})
+
+	// Идентификатор клиента сохраняется
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middlewares.RequestIDHeader, "abc-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "abc-123", seen)
+	assert.Equal(t, "abc-123", w.Header().Get(middlewares.RequestIDHeader))
+
+	// Некорректный заменяется сгенерированным
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middlewares.RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, w.Header().Get(middlewares.RequestIDHeader))
+}
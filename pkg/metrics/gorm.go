@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startedKey = "metrics:started"
+
+// GormPlugin times every statement through GORM callbacks.
+type GormPlugin struct{}
+
+var _ gorm.Plugin = GormPlugin{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("metrics:before_"+h.operation, start); err != nil {
+			return err
+		}
+		if err := h.after("metrics:after_"+h.operation, observe(h.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func start(db *gorm.DB) {
+	db.InstanceSet(startedKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startedKey)
+		if !ok {
+			return
+		}
+		started, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		status := "ok"
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			status = "error"
+		}
+		DBQueryDuration.WithLabelValues(operation, table, status).Observe(time.Since(started).Seconds())
+	}
+}
@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/metrics"
+	"github.com/Quavke/eBookReader/pkg/middlewares"
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	assert.Equal(t, "book", metrics.KeyPrefix("book:42"))
+	assert.Equal(t, "books", metrics.KeyPrefix("books:limit=10,page=1,sort=id"))
+	// Ключ без двоеточия целиком считается префиксом
+	assert.Equal(t, "plain", metrics.KeyPrefix("plain"))
+}
+
+func TestMetricsMiddlewareUsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.MetricsMiddleware())
+	router.GET("/books/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	before := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/books/:id", "200"))
+	for _, path := range []string{"/books/1", "/books/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	// Разные id попадают в одну серию
+	assert.Equal(t, before+2, testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/books/:id", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "unmatched", "404")))
+}
+
+func TestGormPluginObservesQueries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, gormDB.Use(metrics.GormPlugin{}))
+
+	mock.ExpectQuery(`SELECT \* FROM "books"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	var books []models.Book
+	require.NoError(t, gormDB.Find(&books).Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	var labels []string
+	for _, f := range families {
+		if f.GetName() != "ebookreader_db_query_duration_seconds" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			var pairs []string
+			for _, l := range m.GetLabel() {
+				pairs = append(pairs, l.GetName()+"="+l.GetValue())
+			}
+			labels = append(labels, strings.Join(pairs, ","))
+			assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+		}
+	}
+	assert.Equal(t, []string{"operation=query,status=ok,table=books"}, labels)
+}
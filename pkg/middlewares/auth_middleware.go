@@ -12,8 +12,13 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// privateCacheControl keeps responses of authenticated routes, and their 401s,
+// out of shared caches.
+const privateCacheControl = "private, no-store"
+
 func AuthMiddleware(repo repositories.UserRepo) gin.HandlerFunc {
 	return func (c *gin.Context) {
+		c.Header("Cache-Control", privateCacheControl)
 		tokenString, err := c.Cookie("Authorization")
 		if err != nil {
 			c.AbortWithStatus(http.StatusUnauthorized)
@@ -38,7 +43,7 @@ func AuthMiddleware(repo repositories.UserRepo) gin.HandlerFunc {
 			if float64(time.Now().Unix()) < float64(claims.NotBefore.Unix()){
 				c.AbortWithStatus(http.StatusUnauthorized)
 			}
-			if err := repo.IsExists(uint(claims.UserID)); err != nil{
+			if err := repo.IsExists(c.Request.Context(), uint(claims.UserID)); err != nil{
 				c.AbortWithStatus(http.StatusUnauthorized)
 			}
 		}
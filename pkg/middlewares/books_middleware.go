@@ -3,7 +3,7 @@ package middlewares
 import (
 	"github.com/Quavke/eBookReader/pkg/models"
 	"github.com/Quavke/eBookReader/pkg/repositories"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,18 +14,18 @@ func BooksMiddleware(repo repositories.UserRepo) gin.HandlerFunc {
 		claims, exists := c.Get("claims")
         if !exists {
             c.JSON(http.StatusUnauthorized, models.APIResponse[any]{Message: "something went wrong. You may not be logged in."})
-						log.Println("Books middleware error, cannot find claims")
+						slog.WarnContext(c.Request.Context(), "Books middleware error, cannot find claims")
             c.Abort()
             return
         }
         
     userClaims := claims.(*models.Claims)
 
-		isAuthor, err := repo.IsAuthor(userClaims.UserID)
+		isAuthor, err := repo.IsAuthor(c.Request.Context(), userClaims.UserID)
 		
 		if err != nil && !isAuthor {
 			c.JSON(http.StatusInternalServerError, models.APIResponse[any]{Message: "something went wrong. You may not be the author"})
-			log.Printf("Books middleware error, user repo method isAuthor. Error: %s", err.Error())
+			slog.ErrorContext(c.Request.Context(), "Books middleware error, user repo method isAuthor", "error", err)
 			c.Abort()
 			return
 		}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware labels requests with the route template, not the path,
+// so /books/1 and /books/2 share a series. Unmatched paths are grouped too.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(started).Seconds())
+	}
+}
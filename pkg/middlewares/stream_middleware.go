@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamMiddleware cancels the request context of long-lived connections
+// (SSE, WebSocket) once shutdown is cancelled, so they end before the server
+// waits for in-flight requests to drain.
+func StreamMiddleware(shutdown context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		stop := context.AfterFunc(shutdown, cancel)
+		defer stop()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middlewares_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/middlewares"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type existingUsers struct {
+	repositories.UserRepo
+}
+
+func (existingUsers) IsExists(ctx context.Context, id uint) error {
+	return nil
+}
+
+func TestAuthMiddleware_PrivateCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "secret")
+
+	router := gin.New()
+	auth := router.Group("/")
+	auth.Use(middlewares.AuthMiddleware(existingUsers{}))
+	auth.GET("/users/me/feed", func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.APIResponse[any]{Message: "Successful"})
+	})
+
+	token, err := utils.GenerateToken(&models.Claims{
+		UserID: 7,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    "eBookReader",
+		},
+	}, []byte("secret"))
+	require.NoError(t, err)
+
+	// Ответ для пользователя не должен попасть в общий кэш
+	req := httptest.NewRequest(http.MethodGet, "/users/me/feed", nil)
+	req.AddCookie(&http.Cookie{Name: "Authorization", Value: token})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+
+	// Как и отказ без токена
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/me/feed", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+}
@@ -0,0 +1,112 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const Usage = `usage: migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [n]       revert the last n applied migrations (default 1)
+  status         list migrations and when they were applied
+  create <name>  add an empty up/down pair to ` + SourceDir
+
+var ErrUsage = errors.New(Usage)
+
+// Command runs the migrate subcommands. Open is only called by commands
+// that need the database, so create works without one.
+type Command struct {
+	Open func() (*sql.DB, error)
+	Dir  string
+	Out  io.Writer
+}
+
+func (c Command) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	switch args[0] {
+	case "create":
+		if len(args) != 2 {
+			return ErrUsage
+		}
+		up, down, err := Create(c.Dir, args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Out, "created %s\ncreated %s\n", up, down)
+		return nil
+	case "up", "status":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+	case "down":
+		if len(args) > 2 {
+			return ErrUsage
+		}
+	default:
+		return ErrUsage
+	}
+
+	migrations, err := Embedded()
+	if err != nil {
+		return err
+	}
+	db, err := c.Open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m := New(db, migrations)
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Fprintf(c.Out, "applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(c.Out, "schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("down: step count must be a positive integer, got %q", args[1])
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			fmt.Fprintf(c.Out, "reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	default:
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(c.Out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format(time.DateTime)
+			}
+			if s.Unknown {
+				applied += " (not in this build)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	}
+}
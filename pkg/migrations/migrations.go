@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceDir is where create writes new files, relative to the repository
+// root. They are compiled into the binary, so a rebuild picks them up.
+const SourceDir = "pkg/migrations/sql"
+
+// noTransaction marks a migration that cannot run inside a transaction, such
+// as CREATE INDEX CONCURRENTLY. It must be the first line of the file.
+const noTransaction = "-- migrate:no-transaction"
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+var (
+	fileName  = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	validName = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+var ErrInvalidName = errors.New("migration name must be lowercase letters, digits and underscores")
+
+// Migration is one schema change: Up applies it and Down reverts it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Embedded returns the migrations compiled into the binary.
+func Embedded() ([]Migration, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load reads <version>_<name>.up.sql and .down.sql pairs from the root of
+// fsys and returns them ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		match := fileName.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.(up|down).sql", e.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d: named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s: needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Create writes an empty up/down pair to dir, numbered after the newest
+// migration already there, and returns the paths.
+func Create(dir, title string) (up, down string, err error) {
+	if !validName.MatchString(title) {
+		return "", "", ErrInvalidName
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", err
+	}
+	var last int64
+	for _, e := range entries {
+		match := fileName.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		if v, err := strconv.ParseInt(match[1], 10, 64); err == nil && v > last {
+			last = v
+		}
+	}
+	base := fmt.Sprintf("%04d_%s", last+1, title)
+	up = filepath.Join(dir, base+".up.sql")
+	down = filepath.Join(dir, base+".down.sql")
+	if err := os.WriteFile(up, []byte("-- "+title+"\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- revert "+title+"\n"), 0o644); err != nil {
+		os.Remove(up)
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+func transactional(sql string) bool {
+	return !strings.HasPrefix(strings.TrimSpace(sql), noTransaction)
+}
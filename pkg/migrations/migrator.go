@@ -0,0 +1,231 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// lockKey names the Postgres advisory lock held while migrating. Instances
+// that start together queue on it instead of racing the same DDL.
+const lockKey int64 = 0x65626f6f6b // "ebook"
+
+const (
+	createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    bigint PRIMARY KEY,
+    name       text NOT NULL,
+    applied_at timestamptz NOT NULL DEFAULT now()
+)`
+	tableExists   = `SELECT to_regclass('schema_migrations') IS NOT NULL`
+	selectApplied = `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`
+	insertApplied = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	deleteApplied = `DELETE FROM schema_migrations WHERE version = $1`
+)
+
+// Status is a migration and when it was applied; AppliedAt is nil while it
+// is pending. Unknown is set for versions recorded in the database that this
+// binary has no file for, typically applied by a newer release.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	Unknown   bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. A failed migration is rolled back and stops the run.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, mig.Up, insertApplied, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the newest steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(done))
+		for v := range done {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		for _, v := range versions {
+			if len(reverted) == steps {
+				break
+			}
+			mig, ok := known[v]
+			if !ok {
+				return fmt.Errorf("migration %04d is applied but not part of this build", v)
+			}
+			if err := run(ctx, conn, mig.Down, deleteApplied, mig.Version); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration, plus applied versions this build does
+// not know, in version order. It takes no lock.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	done, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Migration: mig}
+		if rec, ok := done[mig.Version]; ok {
+			at := rec.at
+			s.AppliedAt = &at
+			delete(done, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for v, rec := range done {
+		at := rec.at
+		statuses = append(statuses, Status{Migration: Migration{Version: v, Name: rec.name}, AppliedAt: &at, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending returns the migrations not applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	done, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := done[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]record, error) {
+	var exists bool
+	if err := m.db.QueryRowContext(ctx, tableExists).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return map[int64]record{}, nil
+	}
+	return appliedVersions(ctx, m.db)
+}
+
+// locked runs fn on a single connection holding the advisory lock. The lock
+// belongs to the session, so it has to be released on the same connection.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			// Closing the session is the only other way to release the lock;
+			// returning it to the pool would keep the lock held.
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+type record struct {
+	name string
+	at   time.Time
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func appliedVersions(ctx context.Context, q queryer) (map[int64]record, error) {
+	rows, err := q.QueryContext(ctx, selectApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	done := make(map[int64]record)
+	for rows.Next() {
+		var (
+			version int64
+			rec     record
+		)
+		if err := rows.Scan(&version, &rec.name, &rec.at); err != nil {
+			return nil, err
+		}
+		done[version] = rec
+	}
+	return done, rows.Err()
+}
+
+// run executes script and the bookkeeping statement together, so a failed
+// migration leaves no record. Scripts marked no-transaction run bare.
+func run(ctx context.Context, conn *sql.Conn, script, bookkeeping string, args ...any) error {
+	if !transactional(script) {
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		_, err := conn.ExecContext(ctx, bookkeeping, args...)
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
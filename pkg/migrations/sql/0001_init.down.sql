@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS reading_progresses;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS follows;
+DROP TABLE IF EXISTS book_contributors;
+DROP TABLE IF EXISTS books;
+DROP TABLE IF EXISTS works;
+DROP TABLE IF EXISTS authors;
+DROP TABLE IF EXISTS user_dbs;
@@ -0,0 +1,170 @@
+-- Baseline schema, matching what AutoMigrate used to create. Every statement
+-- is IF NOT EXISTS, so on databases created before versioned migrations the
+-- user_dbs, authors and books tables that AutoMigrate built are left alone
+-- here; 0005_adopt_automigrate adds the columns, indexes and keys they lack.
+
+CREATE TABLE IF NOT EXISTS user_dbs (
+    id            bigserial PRIMARY KEY,
+    created_at    timestamptz,
+    updated_at    timestamptz,
+    deleted_at    timestamptz,
+    username      varchar(64) NOT NULL,
+    password_hash bytea NOT NULL,
+    version       bigint NOT NULL DEFAULT 1,
+    is_admin      boolean NOT NULL DEFAULT false
+);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_users_username ON user_dbs (username);
+CREATE INDEX IF NOT EXISTS idx_user_dbs_deleted_at ON user_dbs (deleted_at);
+
+CREATE TABLE IF NOT EXISTS authors (
+    user_id    bigint PRIMARY KEY,
+    firstname  text,
+    lastname   text,
+    birthday   date,
+    bio        text,
+    avatar_key varchar(255),
+    pen_names  jsonb,
+    website    varchar(255),
+    links      jsonb,
+    country    varchar(2),
+    version    bigint NOT NULL DEFAULT 1,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT fk_user_dbs_author FOREIGN KEY (user_id) REFERENCES user_dbs (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_authors_deleted_at ON authors (deleted_at);
+
+CREATE TABLE IF NOT EXISTS works (
+    id          bigserial PRIMARY KEY,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    deleted_at  timestamptz,
+    title       text NOT NULL,
+    original_id bigint NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_works_original_id ON works (original_id);
+CREATE INDEX IF NOT EXISTS idx_works_deleted_at ON works (deleted_at);
+
+CREATE TABLE IF NOT EXISTS books (
+    id              bigserial PRIMARY KEY,
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    deleted_at      timestamptz,
+    title           text NOT NULL,
+    content         text NOT NULL,
+    author_id       bigint NOT NULL,
+    version         bigint NOT NULL DEFAULT 1,
+    word_count      bigint NOT NULL DEFAULT 0,
+    char_count      bigint NOT NULL DEFAULT 0,
+    paragraph_count bigint NOT NULL DEFAULT 0,
+    reading_minutes bigint NOT NULL DEFAULT 0,
+    readability     decimal NOT NULL DEFAULT 0,
+    cover_key       varchar(255),
+    language        varchar(35) NOT NULL DEFAULT 'und',
+    work_id         bigint,
+    CONSTRAINT uni_books_title UNIQUE (title),
+    CONSTRAINT uni_books_content UNIQUE (content),
+    CONSTRAINT fk_authors_books FOREIGN KEY (author_id) REFERENCES authors (user_id) ON UPDATE CASCADE,
+    CONSTRAINT fk_works_editions FOREIGN KEY (work_id) REFERENCES works (id)
+);
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books (deleted_at);
+
+CREATE TABLE IF NOT EXISTS book_contributors (
+    book_id     bigint NOT NULL,
+    author_id   bigint NOT NULL,
+    role        varchar(16) NOT NULL,
+    position    bigint NOT NULL DEFAULT 0,
+    invited_by  bigint NOT NULL,
+    accepted_at timestamptz,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    PRIMARY KEY (book_id, author_id),
+    CONSTRAINT fk_books_contributors FOREIGN KEY (book_id) REFERENCES books (id),
+    CONSTRAINT fk_book_contributors_author FOREIGN KEY (author_id) REFERENCES authors (user_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_book_contributors_author_id ON book_contributors (author_id);
+
+CREATE TABLE IF NOT EXISTS follows (
+    user_id    bigint NOT NULL,
+    author_id  bigint NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (user_id, author_id),
+    CONSTRAINT fk_follows_user FOREIGN KEY (user_id) REFERENCES user_dbs (id) ON DELETE CASCADE,
+    CONSTRAINT fk_follows_author FOREIGN KEY (author_id) REFERENCES authors (user_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_follows_author_id ON follows (author_id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    type       varchar(32) NOT NULL,
+    payload    jsonb,
+    read_at    timestamptz,
+    created_at timestamptz,
+    CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES user_dbs (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS ix_notifications_user_read ON notifications (user_id, read_at);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id bigint NOT NULL,
+    type    varchar(32) NOT NULL,
+    enabled boolean NOT NULL,
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_notification_preferences_user FOREIGN KEY (user_id) REFERENCES user_dbs (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS reading_progresses (
+    user_id           bigint NOT NULL,
+    book_id           bigint NOT NULL,
+    position          bigint NOT NULL,
+    page              bigint NOT NULL DEFAULT 0,
+    device_id         varchar(64) NOT NULL,
+    client_updated_at timestamptz NOT NULL,
+    updated_at        timestamptz,
+    PRIMARY KEY (user_id, book_id),
+    CONSTRAINT fk_reading_progresses_user FOREIGN KEY (user_id) REFERENCES user_dbs (id) ON DELETE CASCADE,
+    CONSTRAINT fk_reading_progresses_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         bigserial PRIMARY KEY,
+    owner_id   bigint NOT NULL,
+    url        varchar(2048) NOT NULL,
+    secret     varchar(128) NOT NULL,
+    events     jsonb NOT NULL,
+    global     boolean NOT NULL DEFAULT false,
+    active     boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_webhooks_owner FOREIGN KEY (owner_id) REFERENCES user_dbs (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_owner_id ON webhooks (owner_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id          bigserial PRIMARY KEY,
+    webhook_id  bigint NOT NULL,
+    delivery_id varchar(64) NOT NULL,
+    event       varchar(32) NOT NULL,
+    attempt     bigint NOT NULL,
+    status_code bigint,
+    error       text,
+    duration_ms bigint,
+    success     boolean NOT NULL,
+    created_at  timestamptz,
+    CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_delivery_id ON webhook_deliveries (delivery_id);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id            bigserial PRIMARY KEY,
+    topic         varchar(64) NOT NULL,
+    aggregate_id  bigint NOT NULL,
+    payload       jsonb NOT NULL,
+    attempts      bigint NOT NULL DEFAULT 0,
+    last_error    text,
+    dispatched_at timestamptz,
+    created_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_dispatched_at ON outbox (dispatched_at);
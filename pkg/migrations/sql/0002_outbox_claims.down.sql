@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS ix_outbox_pending;
+ALTER TABLE outbox DROP COLUMN IF EXISTS claimed_until;
@@ -0,0 +1,4 @@
+-- The relay claims messages with a lease and commits before publishing, so
+-- no row lock or connection is held while subscribers run.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS claimed_until timestamptz;
+CREATE INDEX IF NOT EXISTS ix_outbox_pending ON outbox (id) WHERE dispatched_at IS NULL;
@@ -0,0 +1,3 @@
+-- Subscribers that already handled a message are recorded, so a retry does
+-- not repeat their side effects.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS delivered jsonb;
@@ -0,0 +1,7 @@
+-- Import jobs may run twice after a visibility timeout; the second run finds
+-- the book of the first one here.
+CREATE TABLE IF NOT EXISTS book_imports (
+    job_id     varchar(64) PRIMARY KEY,
+    book_id    bigint NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    created_at timestamptz
+);
@@ -0,0 +1,8 @@
+-- The columns stay: 0001 defines them on new databases, and the application
+-- cannot run without them. Only the indexes and the key are dropped; the
+-- 0001 down migration removes the tables.
+ALTER TABLE books DROP CONSTRAINT IF EXISTS fk_works_editions;
+DROP INDEX IF EXISTS idx_books_work_id;
+DROP INDEX IF EXISTS idx_books_language;
+DROP INDEX IF EXISTS idx_books_reading_minutes;
+DROP INDEX IF EXISTS idx_books_word_count;
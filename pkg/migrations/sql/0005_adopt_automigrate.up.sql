@@ -0,0 +1,42 @@
+-- Databases created before versioned migrations have user_dbs, authors and
+-- books as the original AutoMigrate built them, which 0001 skips. Bring them
+-- to the 0001 definition; on databases that 0001 created this is a no-op.
+
+ALTER TABLE user_dbs
+    ADD COLUMN IF NOT EXISTS version  bigint NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS is_admin boolean NOT NULL DEFAULT false;
+
+ALTER TABLE authors
+    ADD COLUMN IF NOT EXISTS bio        text,
+    ADD COLUMN IF NOT EXISTS avatar_key varchar(255),
+    ADD COLUMN IF NOT EXISTS pen_names  jsonb,
+    ADD COLUMN IF NOT EXISTS website    varchar(255),
+    ADD COLUMN IF NOT EXISTS links      jsonb,
+    ADD COLUMN IF NOT EXISTS country    varchar(2),
+    ADD COLUMN IF NOT EXISTS version    bigint NOT NULL DEFAULT 1;
+
+-- Existing books get zero stats until they are next saved.
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS version         bigint NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS word_count      bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS char_count      bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS paragraph_count bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS reading_minutes bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS readability     decimal NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cover_key       varchar(255),
+    ADD COLUMN IF NOT EXISTS language        varchar(35) NOT NULL DEFAULT 'und',
+    ADD COLUMN IF NOT EXISTS work_id         bigint;
+
+CREATE INDEX IF NOT EXISTS idx_books_word_count ON books (word_count);
+CREATE INDEX IF NOT EXISTS idx_books_reading_minutes ON books (reading_minutes);
+CREATE INDEX IF NOT EXISTS idx_books_language ON books (language);
+CREATE INDEX IF NOT EXISTS idx_books_work_id ON books (work_id);
+
+-- Constraints have no IF NOT EXISTS.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_works_editions') THEN
+        ALTER TABLE books ADD CONSTRAINT fk_works_editions FOREIGN KEY (work_id) REFERENCES works (id);
+    END IF;
+END
+$$;
@@ -0,0 +1,147 @@
+package migrations_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/migrations"
+	"github.com/Quavke/eBookReader/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Таблицы в том виде, в каком их создавал AutoMigrate до версионных миграций.
+type baselineUser struct {
+	gorm.Model
+	Username     string          `gorm:"type:varchar(64);not null;uniqueIndex:ux_users_username"`
+	PasswordHash []byte          `gorm:"not null"`
+	Author       *baselineAuthor `gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (baselineUser) TableName() string { return "user_dbs" }
+
+type baselineAuthor struct {
+	UserID    uint `gorm:"primaryKey;not null;constraint:OnDelete:CASCADE;"`
+	Firstname string
+	Lastname  string
+	Birthday  time.Time      `gorm:"type:date"`
+	Books     []baselineBook `gorm:"foreignKey:AuthorID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineAuthor) TableName() string { return "authors" }
+
+type baselineBook struct {
+	gorm.Model
+	Title    string `gorm:"not null;unique"`
+	Content  string `gorm:"not null;unique"`
+	AuthorID uint   `gorm:"not null;constraint:OnUpdate:CASCADE;"`
+}
+
+func (baselineBook) TableName() string { return "books" }
+
+func columns(t *testing.T, model any) map[string]bool {
+	t.Helper()
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+	cols := make(map[string]bool, len(s.DBNames))
+	for _, name := range s.DBNames {
+		cols[name] = true
+	}
+	return cols
+}
+
+// Каждый столбец, которого нет в старой таблице, должен добавляться в 0005.
+func TestAdoptAutoMigrate_CoversModelColumns(t *testing.T) {
+	up, err := os.ReadFile("../sql/0005_adopt_automigrate.up.sql")
+	require.NoError(t, err)
+	script := string(up)
+
+	pairs := []struct{ current, baseline any }{
+		{&models.UserDB{}, &baselineUser{}},
+		{&models.Author{}, &baselineAuthor{}},
+		{&models.Book{}, &baselineBook{}},
+	}
+	for _, p := range pairs {
+		old := columns(t, p.baseline)
+		for col := range columns(t, p.current) {
+			if old[col] {
+				continue
+			}
+			assert.Contains(t, script, "ADD COLUMN IF NOT EXISTS "+col+" ", "column %s", col)
+		}
+	}
+}
+
+// Интеграционный тест: нужен пустой Postgres в EBOOK_TEST_DATABASE_URL.
+func TestAdoptAutoMigrate_Postgres(t *testing.T) {
+	url := os.Getenv("EBOOK_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("EBOOK_TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	admin, err := gorm.Open(postgres.Open(url), &gorm.Config{})
+	require.NoError(t, err)
+	ns := fmt.Sprintf("adopt_%d", time.Now().UnixNano())
+	require.NoError(t, admin.Exec("CREATE SCHEMA "+ns).Error)
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + ns + " CASCADE") })
+
+	sep := "?"
+	if strings.Contains(url, "?") {
+		sep = "&"
+	}
+	db, err := gorm.Open(postgres.Open(url+sep+"search_path="+ns), &gorm.Config{})
+	require.NoError(t, err)
+
+	// Старая схема с данными.
+	require.NoError(t, db.AutoMigrate(&baselineAuthor{}, &baselineBook{}, &baselineUser{}))
+	user := baselineUser{Username: "legacy", PasswordHash: []byte("hash")}
+	require.NoError(t, db.Create(&user).Error)
+	require.NoError(t, db.Create(&baselineAuthor{UserID: user.ID, Firstname: "Old", Lastname: "Author", Birthday: time.Now()}).Error)
+	require.NoError(t, db.Create(&baselineBook{Title: "Legacy", Content: "legacy content", AuthorID: user.ID}).Error)
+
+	all, err := migrations.Embedded()
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	_, err = migrations.New(sqlDB, all).Up(ctx)
+	require.NoError(t, err)
+
+	// Текущие модели читаются без ошибок, у старых строк значения по умолчанию.
+	var u models.UserDB
+	require.NoError(t, db.First(&u, user.ID).Error)
+	assert.EqualValues(t, 1, u.Version)
+	assert.False(t, u.IsAdmin)
+
+	var a models.Author
+	require.NoError(t, db.First(&a, "user_id = ?", user.ID).Error)
+	assert.EqualValues(t, 1, a.Version)
+
+	var b models.Book
+	require.NoError(t, db.First(&b, "title = ?", "Legacy").Error)
+	assert.EqualValues(t, 1, b.Version)
+	assert.Equal(t, "und", b.Language)
+	assert.Nil(t, b.WorkID)
+
+	var fks int64
+	require.NoError(t, db.Raw("SELECT count(*) FROM pg_constraint WHERE conname = 'fk_works_editions' AND connamespace = ?::regnamespace", ns).Scan(&fks).Error)
+	assert.EqualValues(t, 1, fks)
+
+	for _, idx := range []string{"idx_books_word_count", "idx_books_reading_minutes", "idx_books_language", "idx_books_work_id"} {
+		var n int64
+		require.NoError(t, db.Raw("SELECT count(*) FROM pg_indexes WHERE schemaname = ? AND indexname = ?", ns, idx).Scan(&n).Error)
+		assert.EqualValues(t, 1, n, idx)
+	}
+}
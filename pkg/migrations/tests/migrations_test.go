@@ -0,0 +1,237 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/migrations"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	lockQuery      = regexp.QuoteMeta("SELECT pg_advisory_lock($1)")
+	unlockQuery    = regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")
+	createTable    = regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")
+	tableExists    = regexp.QuoteMeta("SELECT to_regclass('schema_migrations') IS NOT NULL")
+	selectApplied  = regexp.QuoteMeta("SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	insertApplied  = regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")
+	deleteApplied  = regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")
+	appliedColumns = []string{"version", "name", "applied_at"}
+)
+
+func testMigrations() []migrations.Migration {
+	return []migrations.Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE a (id int)", Down: "DROP TABLE a"},
+		{Version: 2, Name: "add_b", Up: "CREATE TABLE b (id int)", Down: "DROP TABLE b"},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_b.up.sql":   {Data: []byte("CREATE TABLE b (id int);")},
+		"0002_add_b.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0001_init.up.sql":    {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_init.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"README.md":           {Data: []byte("не миграция")},
+	}
+
+	loaded, err := migrations.Load(fsys)
+	require.NoError(t, err)
+	// Миграции упорядочены по версии, а не по имени файла
+	require.Len(t, loaded, 2)
+	assert.Equal(t, int64(1), loaded[0].Version)
+	assert.Equal(t, "init", loaded[0].Name)
+	assert.Equal(t, "DROP TABLE a;", loaded[0].Down)
+	assert.Equal(t, int64(2), loaded[1].Version)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	// Без down-файла миграцию нельзя откатить
+	_, err := migrations.Load(fstest.MapFS{
+		"0001_init.up.sql": {Data: []byte("CREATE TABLE a (id int);")},
+	})
+	assert.Error(t, err)
+
+	// Имя файла не по шаблону
+	_, err = migrations.Load(fstest.MapFS{
+		"init.up.sql": {Data: []byte("CREATE TABLE a (id int);")},
+	})
+	assert.Error(t, err)
+
+	// Одна версия с разными именами
+	_, err = migrations.Load(fstest.MapFS{
+		"0001_init.up.sql":    {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_other.down.sql": {Data: []byte("DROP TABLE a;")},
+	})
+	assert.Error(t, err)
+}
+
+func TestEmbedded(t *testing.T) {
+	loaded, err := migrations.Embedded()
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+	assert.Equal(t, int64(1), loaded[0].Version)
+	assert.Equal(t, "init", loaded[0].Name)
+	assert.Contains(t, loaded[0].Up, "CREATE TABLE IF NOT EXISTS books")
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0007_old.up.sql"), []byte("SELECT 1;"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0007_old.down.sql"), []byte("SELECT 1;"), 0o644))
+
+	up, down, err := migrations.Create(dir, "add_search_index")
+	require.NoError(t, err)
+	// Номер следует за последней существующей миграцией
+	assert.Equal(t, filepath.Join(dir, "0008_add_search_index.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "0008_add_search_index.down.sql"), down)
+
+	loaded, err := migrations.Load(os.DirFS(dir))
+	require.NoError(t, err)
+	assert.Len(t, loaded, 2)
+
+	_, _, err = migrations.Create(dir, "Bad Name")
+	assert.ErrorIs(t, err, migrations.ErrInvalidName)
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Первая миграция уже применена, вторая выполняется в транзакции под блокировкой
+	mock.ExpectExec(lockQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows(appliedColumns).AddRow(1, "init", time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id int)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insertApplied).WithArgs(2, "add_b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(unlockQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrations.New(db, testMigrations()).Up(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, int64(2), applied[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_UpFailureRollsBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Ошибка в миграции: транзакция откатывается, запись не создаётся, блокировка снимается
+	mock.ExpectExec(lockQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows(appliedColumns))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE a (id int)")).WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	mock.ExpectExec(unlockQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrations.New(db, testMigrations()).Up(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "0001_init")
+	assert.Empty(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_UpWithoutTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	concurrent := migrations.Migration{
+		Version: 1,
+		Name:    "search_index",
+		Up:      "-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY ix ON books (title)",
+		Down:    "DROP INDEX ix",
+	}
+
+	// CREATE INDEX CONCURRENTLY нельзя выполнять внутри транзакции
+	mock.ExpectExec(lockQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows(appliedColumns))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX CONCURRENTLY")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insertApplied).WithArgs(1, "search_index").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(unlockQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = migrations.New(db, []migrations.Migration{concurrent}).Up(context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Откатывается только последняя применённая миграция
+	mock.ExpectExec(lockQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows(appliedColumns).
+		AddRow(1, "init", time.Now()).
+		AddRow(2, "add_b", time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE b")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(deleteApplied).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(unlockQuery).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := migrations.New(db, testMigrations()).Down(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, int64(2), reverted[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Pending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	m := migrations.New(db, testMigrations())
+
+	// Таблицы ещё нет: все миграции ожидают применения
+	mock.ExpectQuery(tableExists).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	pending, err := m.Pending(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	mock.ExpectQuery(tableExists).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows(appliedColumns).AddRow(1, "init", time.Now()))
+	pending, err = m.Pending(context.Background())
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "add_b", pending[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_StatusReportsUnknownVersions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Версия 3 применена более новой сборкой
+	mock.ExpectQuery(tableExists).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows(appliedColumns).
+		AddRow(1, "init", time.Now()).
+		AddRow(3, "from_future", time.Now()))
+
+	statuses, err := migrations.New(db, testMigrations()).Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	assert.True(t, statuses[2].Unknown)
+	assert.Equal(t, "from_future", statuses[2].Name)
+}
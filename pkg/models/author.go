@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -39,13 +40,65 @@ func (d *DateOnly) Scan(value interface{}) error {
 func (d DateOnly) Value() (driver.Value, error) {
     return d.Time, nil
 }
+type StringList []string
+
+func (l *StringList) Scan(value interface{}) error {
+    return scanJSON(value, l)
+}
+
+func (l StringList) Value() (driver.Value, error) {
+    return valueJSON(l)
+}
+
+type AuthorLink struct {
+    Kind string `json:"kind" binding:"required,min=1,max=32"`
+    URL  string `json:"url"  binding:"required,url,max=255"`
+}
+
+type AuthorLinks []AuthorLink
+
+func (l *AuthorLinks) Scan(value interface{}) error {
+    return scanJSON(value, l)
+}
+
+func (l AuthorLinks) Value() (driver.Value, error) {
+    return valueJSON(l)
+}
+
+func scanJSON(value interface{}, dst any) error {
+    switch val := value.(type) {
+    case nil:
+        return nil
+    case []byte:
+        return json.Unmarshal(val, dst)
+    case string:
+        return json.Unmarshal([]byte(val), dst)
+    }
+    return fmt.Errorf("cannot scan value %v into %T", value, dst)
+}
+
+func valueJSON(v any) (driver.Value, error) {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return nil, err
+    }
+    return string(data), nil
+}
+
 type Author struct {
     UserID    uint      `json:"-" gorm:"primaryKey;not null;constraint:OnDelete:CASCADE;"`
     User      *UserDB   `json:"-" gorm:"foreignKey:UserID;references:ID"`
 	Firstname string    `json:"Firstname" binding:"required"`
 	Lastname  string    `json:"Lastname"  binding:"required"`
 	Birthday  DateOnly  `json:"Birthday"  binding:"required" gorm:"type:date"`
+	Bio       string      `json:"Bio"      binding:"omitempty,max=20000" gorm:"type:text"`
+	AvatarKey string      `json:"-"        gorm:"type:varchar(255)"`
+	PenNames  StringList  `json:"PenNames" binding:"omitempty,max=10,dive,min=1,max=100" gorm:"type:jsonb"`
+	Website   string      `json:"Website"  binding:"omitempty,url,max=255" gorm:"type:varchar(255)"`
+	Links     AuthorLinks `json:"Links"    binding:"omitempty,max=20,dive" gorm:"type:jsonb"`
+	Country   string      `json:"Country"  binding:"omitempty,iso3166_1_alpha2" gorm:"type:varchar(2)"`
 	Books     []Book    `gorm:"foreignKey:AuthorID"`
+    Version   uint      `json:"-" gorm:"not null;default:1"`
     CreatedAt time.Time
     UpdatedAt time.Time
     DeletedAt gorm.DeletedAt `gorm:"index"`
@@ -56,10 +109,33 @@ type AuthorResp struct {
     Firstname string    `json:"firstname"`
     Lastname  string    `json:"lastname"`
     Birthday  DateOnly  `json:"birthday"`
+    Bio       string      `json:"bio,omitempty"`
+    Avatar    *ImageURLs  `json:"avatar,omitempty"`
+    PenNames  StringList  `json:"pen_names,omitempty"`
+    Website   string      `json:"website,omitempty"`
+    Links     AuthorLinks `json:"links,omitempty"`
+    Country   string      `json:"country,omitempty"`
+    Followers int64       `json:"followers"`
+    Version   uint      `json:"version"`
+    UpdatedAt time.Time `json:"updated_at"`
+    Books     *Pagination `json:"books,omitempty"`
 }
 
+// UpdateAuthorReq changes only the fields that are sent. Bio, Website and
+// Country are optional, so sending "" clears them; pen_names and links are
+// cleared with an empty list.
 type UpdateAuthorReq struct {
     Firstname string `json:"firstname,omitempty"`
     Lastname string `json:"lastname,omitempty"`
     Birthday DateOnly `json:"birthday,omitempty"`
+    Bio *string `json:"bio,omitempty" binding:"omitnil,max=20000"`
+    PenNames StringList `json:"pen_names,omitempty" binding:"omitempty,max=10,dive,min=1,max=100"`
+    Website *string `json:"website,omitempty" binding:"omitnil,max=255,eq=|url"`
+    Links AuthorLinks `json:"links,omitempty" binding:"omitempty,max=20,dive"`
+    Country *string `json:"country,omitempty" binding:"omitnil,eq=|iso3166_1_alpha2"`
+}
+
+func (r *UpdateAuthorReq) IsEmpty() bool {
+    return r.Firstname == "" && r.Lastname == "" && r.Birthday.IsZero() &&
+        r.Bio == nil && r.PenNames == nil && r.Website == nil && r.Links == nil && r.Country == nil
 }
\ No newline at end of file
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -10,11 +12,32 @@ type Book struct {
 	Content   string `json:"content" gorm:"not null;unique" binding:"required,min=10"`
 	AuthorID  uint   `json:"-" gorm:"not null;constraint:OnUpdate:CASCADE;"`
 	Author    *Author `json:"-" gorm:"foreignKey:AuthorID;references:UserID"`
+	Version   uint   `json:"-" gorm:"not null;default:1"`
+	TextStats        `json:"-" gorm:"embedded"`
+	CoverKey  string `json:"-" gorm:"type:varchar(255)"`
+	Language  string `json:"language" gorm:"type:varchar(35);not null;default:'und';index" binding:"omitempty,bcp47_language_tag"`
+	WorkID    *uint  `json:"-" gorm:"index"`
+	Contributors []BookContributor `json:"-" gorm:"foreignKey:BookID"`
 }
 
 type BookResp struct {
   ID       uint   `json:"id"`
   Title    string `json:"title"`
-  Content  string `json:"content"`
+  Content  string `json:"content,omitempty"`
   AuthorID uint   `json:"author_id"`
-}
\ No newline at end of file
+  Contributors []ContributorResp `json:"contributors,omitempty"`
+  Language string `json:"language"`
+  WorkID   *uint  `json:"work_id,omitempty"`
+  Version  uint   `json:"version"`
+  Stats    TextStats `json:"stats"`
+  Cover    *ImageURLs `json:"cover,omitempty"`
+  UpdatedAt time.Time `json:"updated_at"`
+}
+type BookContent struct {
+  BookID      uint
+  Format      string
+  ContentType string
+  Body        []byte
+  Version     uint
+  UpdatedAt   time.Time
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	RoleAuthor      = "author"
+	RoleEditor      = "editor"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+)
+
+// BookContributor links an additional author to a book. The primary author
+// stays in Book.AuthorID; a contributor may edit the book only after
+// accepting the invitation.
+type BookContributor struct {
+	BookID     uint       `json:"book_id" gorm:"primaryKey;autoIncrement:false"`
+	AuthorID   uint       `json:"author_id" gorm:"primaryKey;autoIncrement:false;index"`
+	Author     *Author    `json:"-" gorm:"foreignKey:AuthorID;references:UserID;constraint:OnDelete:CASCADE;"`
+	Role       string     `json:"role" gorm:"type:varchar(16);not null"`
+	Position   uint       `json:"position" gorm:"not null;default:0"`
+	InvitedBy  uint       `json:"invited_by" gorm:"not null"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type InviteContributorReq struct {
+	AuthorID uint   `json:"author_id" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=author editor translator illustrator"`
+	Position uint   `json:"position"`
+}
+
+type ContributorResp struct {
+	AuthorID uint   `json:"author_id"`
+	Role     string `json:"role"`
+	Position uint   `json:"position"`
+	Primary  bool   `json:"primary,omitempty"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "encoding/json"
+
+const (
+	EventNotification    = "notification"
+	EventReadingProgress = "reading_progress"
+	EventBookUpdated     = "book_updated"
+)
+
+// Event is a message pushed to a user's open streams. ID is assigned by the
+// broker and is what clients send back as Last-Event-ID.
+type Event struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Follow subscribes a user to an author's new releases.
+type Follow struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false"`
+	User      *UserDB   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;"`
+	AuthorID  uint      `gorm:"primaryKey;autoIncrement:false;index"`
+	Author    *Author   `gorm:"foreignKey:AuthorID;references:UserID;constraint:OnDelete:CASCADE;"`
+	CreatedAt time.Time
+}
+
+// FeedPage is one page of a cursor-paginated feed. NextCursor is empty on the
+// last page.
+type FeedPage struct {
+	Items      []BookResp `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// FeedCursor points just past the last book returned in a FeedPage.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
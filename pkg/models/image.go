@@ -0,0 +1,6 @@
+package models
+
+type ImageURLs struct {
+	Original   string            `json:"original"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobDead      = "dead"
+)
+
+const (
+	JobBookImport = "book.import"
+	JobBookExport = "book.export"
+)
+
+// Job is a unit of background work. Status is JobFailed while a failed job
+// waits for its next attempt and JobDead once it has run out of attempts.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	OwnerID     uint            `json:"owner_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    uint            `json:"attempts"`
+	MaxAttempts uint            `json:"max_attempts"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	RunAfter    time.Time       `json:"run_after"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type JobResp struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Status    string          `json:"status"`
+	Attempts  uint            `json:"attempts"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type ImportJob struct {
+	BlobKey  string `json:"blob_key"`
+	AuthorID uint   `json:"author_id"`
+}
+
+// BookImport records the book an import job created, so a redelivered job
+// returns that book instead of creating another one.
+type BookImport struct {
+	JobID     string `gorm:"primaryKey;type:varchar(64)"`
+	BookID    uint   `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+type ImportResult struct {
+	BookID uint   `json:"book_id"`
+	Title  string `json:"title"`
+}
+
+type ExportJob struct {
+	AuthorID uint   `json:"author_id"`
+	BookIDs  []uint `json:"book_ids,omitempty"`
+	Format   string `json:"format"`
+}
+
+type ExportReq struct {
+	BookIDs []uint `json:"book_ids" binding:"omitempty,max=500"`
+	Format  string `json:"format" binding:"omitempty,oneof=txt html"`
+}
+
+type ExportResult struct {
+	URL   string `json:"url"`
+	Books int    `json:"books"`
+}
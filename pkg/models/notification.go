@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+const (
+	NotificationNewBook      = "new_book"
+	NotificationBookReview   = "book_review"
+	NotificationCommentReply = "comment_reply"
+)
+
+// NotificationTypes lists every type a user can switch on or off.
+var NotificationTypes = []string{NotificationNewBook, NotificationBookReview, NotificationCommentReply}
+
+type NotificationPayload map[string]any
+
+func (p *NotificationPayload) Scan(value interface{}) error {
+	return scanJSON(value, p)
+}
+
+func (p NotificationPayload) Value() (driver.Value, error) {
+	return valueJSON(p)
+}
+
+type Notification struct {
+	ID        uint                `gorm:"primaryKey"`
+	UserID    uint                `gorm:"not null;index:ix_notifications_user_read,priority:1"`
+	User      *UserDB             `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;"`
+	Type      string              `gorm:"type:varchar(32);not null"`
+	Payload   NotificationPayload `gorm:"type:jsonb"`
+	ReadAt    *time.Time          `gorm:"index:ix_notifications_user_read,priority:2"`
+	CreatedAt time.Time
+}
+
+// NotificationPreference stores an explicit opt-out or opt-in. A missing row
+// means the type is delivered.
+type NotificationPreference struct {
+	UserID  uint   `gorm:"primaryKey;autoIncrement:false"`
+	User    *UserDB `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;"`
+	Type    string `gorm:"primaryKey;type:varchar(32)"`
+	Enabled bool   `gorm:"not null"`
+}
+
+type NotificationResp struct {
+	ID        uint                `json:"id"`
+	Type      string              `json:"type"`
+	Payload   NotificationPayload `json:"payload,omitempty"`
+	Read      bool                `json:"read"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+type NotificationInbox struct {
+	UnreadCount   int64       `json:"unread_count"`
+	Notifications *Pagination `json:"notifications"`
+}
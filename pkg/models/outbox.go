@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+const (
+	TopicBookCreated   = "book.created"
+	TopicBookUpdated   = "book.updated"
+	TopicBookDeleted   = "book.deleted"
+	TopicAuthorCreated = "author.created"
+	TopicAuthorUpdated = "author.updated"
+	TopicAuthorDeleted = "author.deleted"
+	TopicUserCreated   = "user.created"
+	TopicUserUpdated   = "user.updated"
+	TopicUserDeleted   = "user.deleted"
+)
+
+type RawJSON json.RawMessage
+
+func (j *RawJSON) Scan(value interface{}) error {
+	switch val := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], val...)
+	case string:
+		*j = RawJSON(val)
+	}
+	return nil
+}
+
+func (j RawJSON) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// OutboxMessage is written in the same transaction as the change it describes
+// and later handed to the EventBus by the relay.
+type OutboxMessage struct {
+	ID           uint       `gorm:"primaryKey"`
+	Topic        string     `gorm:"type:varchar(64);not null"`
+	AggregateID  uint       `gorm:"not null"`
+	Payload      RawJSON    `gorm:"type:jsonb;not null"`
+	Attempts     uint       `gorm:"not null;default:0"`
+	LastError    string     `gorm:"type:text"`
+	DispatchedAt *time.Time `gorm:"index"`
+	// ClaimedUntil is the lease of the relay publishing the message; once
+	// it runs out another relay may take the message over.
+	ClaimedUntil *time.Time
+	// Delivered names the subscribers that already handled the message, so
+	// a retry only runs the ones that failed.
+	Delivered StringList `gorm:"type:jsonb"`
+	CreatedAt time.Time
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
+
+// DomainEvent is an outbox message as seen by EventBus subscribers. Delivery
+// is at least once, so handlers must tolerate seeing an ID twice.
+type DomainEvent struct {
+	ID          uint            `json:"id"`
+	Topic       string          `json:"topic"`
+	AggregateID uint            `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+}
+
+// BookEvent is the payload of book topics. Content is left out to keep
+// outbox rows small.
+type BookEvent struct {
+	ID        uint      `json:"id"`
+	AuthorID  uint      `json:"author_id"`
+	Title     string    `json:"title"`
+	Language  string    `json:"language"`
+	WorkID    *uint     `json:"work_id,omitempty"`
+	Version   uint      `json:"version"`
+	CoverKey  string    `json:"cover_key,omitempty"`
+	Stats     TextStats `json:"stats"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewBookEvent(b *Book) BookEvent {
+	return BookEvent{
+		ID:        b.ID,
+		AuthorID:  b.AuthorID,
+		Title:     b.Title,
+		Language:  b.Language,
+		WorkID:    b.WorkID,
+		Version:   b.Version,
+		CoverKey:  b.CoverKey,
+		Stats:     b.TextStats,
+		UpdatedAt: b.UpdatedAt,
+	}
+}
+
+func (e BookEvent) Book() *Book {
+	b := &Book{
+		AuthorID:  e.AuthorID,
+		Title:     e.Title,
+		Language:  e.Language,
+		WorkID:    e.WorkID,
+		Version:   e.Version,
+		CoverKey:  e.CoverKey,
+		TextStats: e.Stats,
+	}
+	b.ID = e.ID
+	b.UpdatedAt = e.UpdatedAt
+	return b
+}
+
+// EntityEvent is the payload of author and user topics.
+type EntityEvent struct {
+	ID      uint `json:"id"`
+	Version uint `json:"version,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+type PageSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type PageIndex struct {
+	BookID  uint       `json:"book_id"`
+	Version uint       `json:"version"`
+	Unit    string     `json:"unit"`
+	Size    uint       `json:"size"`
+	Pages   []PageSpan `json:"pages"`
+}
+
+type BookPage struct {
+	BookID     uint   `json:"book_id"`
+	Version    uint   `json:"version"`
+	Page       uint   `json:"page"`
+	TotalPages uint   `json:"total_pages"`
+	Unit       string `json:"unit"`
+	Size       uint   `json:"size"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	Text       string `json:"text"`
+}
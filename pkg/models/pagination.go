@@ -2,6 +2,7 @@ package models
 
 import (
 	"math"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,6 +14,7 @@ type Pagination struct {
 	TotalRows  uint64       `json:"total_rows"`
 	TotalPages uint         `json:"total_pages"`
 	Rows       any         `json:"rows"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
 }
 
 func (p *Pagination) GetOffset() uint {
@@ -40,6 +42,13 @@ func (p *Pagination) GetSort() string {
 	return p.Sort
 }
 
+func (p *Pagination) Touch(updatedAt time.Time) {
+	if p.LastModified == nil || updatedAt.After(*p.LastModified) {
+		t := updatedAt
+		p.LastModified = &t
+	}
+}
+
 func Paginate(value any, pagination *Pagination, db *gorm.DB) func(db *gorm.DB) *gorm.DB {
 	var totalRows int64
 	db.Model(value).Count(&totalRows)
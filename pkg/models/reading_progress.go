@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	SyncProgress = "progress"
+	SyncAck      = "ack"
+	SyncConflict = "conflict"
+	SyncError    = "error"
+)
+
+// ReadingProgress is a user's place in a book. ClientUpdatedAt is the time the
+// device recorded the position and decides which of two devices wins.
+type ReadingProgress struct {
+	UserID          uint      `gorm:"primaryKey;autoIncrement:false"`
+	User            *UserDB   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;"`
+	BookID          uint      `gorm:"primaryKey;autoIncrement:false"`
+	Book            *Book     `gorm:"foreignKey:BookID;references:ID;constraint:OnDelete:CASCADE;"`
+	Position        uint      `gorm:"not null"`
+	Page            uint      `gorm:"not null;default:0"`
+	DeviceID        string    `gorm:"type:varchar(64);not null"`
+	ClientUpdatedAt time.Time `gorm:"not null"`
+	UpdatedAt       time.Time
+}
+
+type ProgressReq struct {
+	Position  uint      `json:"position"`
+	Page      uint      `json:"page"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ProgressResp struct {
+	BookID    uint      `json:"book_id"`
+	Position  uint      `json:"position"`
+	Page      uint      `json:"page"`
+	DeviceID  string    `json:"device_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SyncMessage is what the server sends on a reading sync socket.
+type SyncMessage struct {
+	Type     string        `json:"type"`
+	Progress *ProgressResp `json:"progress,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "gorm.io/gorm"
+
+type TextStats struct {
+	WordCount      int     `json:"word_count" gorm:"not null;default:0;index"`
+	CharCount      int     `json:"char_count" gorm:"not null;default:0"`
+	ParagraphCount int     `json:"paragraph_count" gorm:"not null;default:0"`
+	ReadingMinutes int     `json:"reading_minutes" gorm:"not null;default:0;index"`
+	Readability    float64 `json:"readability" gorm:"not null;default:0"`
+}
+
+type BookFilter struct {
+	MinWords       uint
+	MaxWords       uint
+	MinMinutes     uint
+	MaxMinutes     uint
+	MinReadability *float64
+	MaxReadability *float64
+	Language       string
+}
+
+func (f *BookFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.MinWords > 0 {
+		db = db.Where("word_count >= ?", f.MinWords)
+	}
+	if f.MaxWords > 0 {
+		db = db.Where("word_count <= ?", f.MaxWords)
+	}
+	if f.MinMinutes > 0 {
+		db = db.Where("reading_minutes >= ?", f.MinMinutes)
+	}
+	if f.MaxMinutes > 0 {
+		db = db.Where("reading_minutes <= ?", f.MaxMinutes)
+	}
+	if f.MinReadability != nil {
+		db = db.Where("readability >= ?", *f.MinReadability)
+	}
+	if f.MaxReadability != nil {
+		db = db.Where("readability <= ?", *f.MaxReadability)
+	}
+	if f.Language != "" {
+		db = db.Where("language = ?", f.Language)
+	}
+	return db
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
@@ -19,11 +21,15 @@ type UserDB struct {
 	Username     string    `gorm:"type:varchar(64);not null;uniqueIndex:ux_users_username"`
 	PasswordHash []byte    `json:"-" gorm:"not null"`
 	Author       *Author 	 `json:"-" gorm:"foreignKey:UserID;references:ID"`
+	Version      uint      `json:"-" gorm:"not null;default:1"`
+	IsAdmin      bool      `json:"-" gorm:"not null;default:false"`
 }
 
 type UserResp struct {
 	Username string     `json:"username"`
 	IsAuthor bool     	`json:"author"`
+	Version  uint       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 type Claims struct {
 	UserID uint `json:"user_id"`
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Books are published when they are created, so book.created and
+// book.published are emitted together.
+const (
+	WebhookBookCreated   = "book.created"
+	WebhookBookUpdated   = "book.updated"
+	WebhookBookPublished = "book.published"
+	WebhookBookDeleted   = "book.deleted"
+	WebhookPing          = "ping"
+)
+
+var WebhookEvents = []string{WebhookBookCreated, WebhookBookUpdated, WebhookBookPublished, WebhookBookDeleted}
+
+// Webhook receives events for its owner's books, or for every book when
+// Global is set, which only admins may do.
+type Webhook struct {
+	ID        uint       `gorm:"primaryKey"`
+	OwnerID   uint       `gorm:"not null;index"`
+	Owner     *UserDB    `gorm:"foreignKey:OwnerID;references:ID;constraint:OnDelete:CASCADE;"`
+	URL       string     `gorm:"type:varchar(2048);not null"`
+	Secret    string     `gorm:"type:varchar(128);not null"`
+	Events    StringList `gorm:"type:jsonb;not null"`
+	Global    bool       `gorm:"not null;default:false"`
+	Active    bool       `gorm:"not null;default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WebhookDelivery records one HTTP attempt; retries of the same event share
+// a DeliveryID.
+type WebhookDelivery struct {
+	ID         uint     `gorm:"primaryKey"`
+	WebhookID  uint     `gorm:"not null;index"`
+	Webhook    *Webhook `gorm:"foreignKey:WebhookID;references:ID;constraint:OnDelete:CASCADE;"`
+	DeliveryID string   `gorm:"type:varchar(64);not null;index"`
+	Event      string   `gorm:"type:varchar(32);not null"`
+	Attempt    uint     `gorm:"not null"`
+	StatusCode int
+	Error      string `gorm:"type:text"`
+	DurationMs int64
+	Success    bool `gorm:"not null"`
+	CreatedAt  time.Time
+}
+
+type CreateWebhookReq struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=128"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=book.created book.updated book.published book.deleted"`
+	Global bool     `json:"global"`
+}
+
+// WebhookResp carries Secret only in the response to creation.
+type WebhookResp struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Global    bool      `json:"global"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryResp struct {
+	DeliveryID string    `json:"delivery_id"`
+	Event      string    `json:"event"`
+	Attempt    uint      `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Success    bool      `json:"success"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook.
+type WebhookPayload struct {
+	ID        string          `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
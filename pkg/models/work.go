@@ -0,0 +1,30 @@
+package models
+
+import "gorm.io/gorm"
+
+// Work groups the editions of one book: the original and its translations.
+type Work struct {
+	gorm.Model
+	Title      string `gorm:"not null"`
+	OriginalID uint   `gorm:"not null;uniqueIndex"`
+	Editions   []Book `gorm:"foreignKey:WorkID"`
+}
+
+type LinkTranslationReq struct {
+	BookID uint `json:"book_id" binding:"required"`
+}
+
+type EditionResp struct {
+	BookID   uint   `json:"book_id"`
+	Title    string `json:"title"`
+	Language string `json:"language"`
+	Original bool   `json:"original,omitempty"`
+}
+
+type WorkResp struct {
+	ID         uint          `json:"id"`
+	Title      string        `json:"title"`
+	OriginalID uint          `json:"original_id"`
+	Editions   []EditionResp `json:"editions"`
+	Edition    *BookResp     `json:"edition,omitempty"`
+}
@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+// AllTopics subscribes a handler to every topic.
+const AllTopics = "*"
+
+type Handler func(ctx context.Context, event models.DomainEvent) error
+
+type EventBus interface {
+	// Publish runs the handlers of event that are not in delivered and
+	// returns delivered extended by the ones that succeeded.
+	Publish(ctx context.Context, event models.DomainEvent, delivered []string) ([]string, error)
+	// Subscribe registers handler under name, which must be unique among
+	// the handlers of topic and AllTopics; it is what delivery is tracked by.
+	Subscribe(topic, name string, handler Handler)
+}
+
+type namedHandler struct {
+	name    string
+	handler Handler
+}
+
+// LocalBus calls subscribers in-process, in the order they subscribed. Every
+// handler runs even if an earlier one fails; the errors are joined so the
+// relay retries the message, and only the failed handlers run again.
+type LocalBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]namedHandler
+}
+
+var _ EventBus = (*LocalBus)(nil)
+
+func NewLocalBus() *LocalBus {
+	return &LocalBus{handlers: make(map[string][]namedHandler)}
+}
+
+func (b *LocalBus) Subscribe(topic, name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, h := range b.handlers[topic] {
+		if h.name == name {
+			panic(fmt.Sprintf("outbox: handler %q subscribed twice to %s", name, topic))
+		}
+	}
+	b.handlers[topic] = append(b.handlers[topic], namedHandler{name: name, handler: handler})
+}
+
+func (b *LocalBus) Publish(ctx context.Context, event models.DomainEvent, delivered []string) ([]string, error) {
+	b.mu.RLock()
+	handlers := append(append([]namedHandler(nil), b.handlers[event.Topic]...), b.handlers[AllTopics]...)
+	b.mu.RUnlock()
+
+	delivered = slices.Clip(delivered)
+	var errs []error
+	for _, h := range handlers {
+		if slices.Contains(delivered, h.name) {
+			continue
+		}
+		if err := h.handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s handler %s: %w", event.Topic, h.name, err))
+			continue
+		}
+		delivered = append(delivered, h.name)
+	}
+	return delivered, errors.Join(errs...)
+}
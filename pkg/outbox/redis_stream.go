@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const streamRetention = 100000
+
+// StreamHandler appends every event to a Redis stream, where consumers outside
+// this process can read them with consumer groups.
+func StreamHandler(client *redis.Client, stream string) Handler {
+	return func(ctx context.Context, e models.DomainEvent) error {
+		return client.XAdd(ctx, &redis.XAddArgs{
+			Stream: stream,
+			MaxLen: streamRetention,
+			Approx: true,
+			Values: map[string]any{
+				"outbox_id":    strconv.FormatUint(uint64(e.ID), 10),
+				"topic":        e.Topic,
+				"aggregate_id": strconv.FormatUint(uint64(e.AggregateID), 10),
+				"payload":      string(e.Payload),
+				"occurred_at":  e.OccurredAt.UTC().Format(time.RFC3339Nano),
+			},
+		}).Err()
+	}
+}
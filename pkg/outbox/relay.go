@@ -0,0 +1,103 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/metrics"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+)
+
+const (
+	batchSize = 50
+	// claimLease is how long a claimed batch is reserved for this relay.
+	// Messages it has not published by then are taken over by another one.
+	claimLease = 5 * time.Minute
+	// MaxAttempts is how often a message is tried before it is left in the
+	// table for an operator to look at; the last failure is logged and
+	// counted in outbox_dead_letters_total.
+	MaxAttempts = 10
+)
+
+// Relay moves committed outbox messages onto the EventBus.
+type Relay struct {
+	repo     repositories.OutboxRepo
+	bus      EventBus
+	interval time.Duration
+}
+
+func NewRelay(repo repositories.OutboxRepo, bus EventBus, interval time.Duration) *Relay {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Relay{repo: repo, bus: bus, interval: interval}
+}
+
+// Run polls until ctx is cancelled, draining the backlog on every tick.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Drain(ctx); err != nil {
+			slog.ErrorContext(ctx, "Outbox relay error, dispatch", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain dispatches batches until none is full, so a burst is cleared without
+// waiting for further ticks.
+func (r *Relay) Drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		messages, err := r.repo.Claim(ctx, batchSize, MaxAttempts, claimLease)
+		if err != nil {
+			return err
+		}
+		for _, m := range messages {
+			r.publish(ctx, m)
+		}
+		if len(messages) < batchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// publish records the outcome even when ctx is cancelled mid-way, so a
+// shutdown does not leave the message claimed until the lease expires.
+func (r *Relay) publish(ctx context.Context, m models.OutboxMessage) {
+	delivered, err := r.bus.Publish(ctx, DomainEvent(m), m.Delivered)
+	record := context.WithoutCancel(ctx)
+	if err == nil {
+		if err := r.repo.Complete(record, m.ID); err != nil {
+			slog.ErrorContext(ctx, "Outbox relay error, complete", "outbox_id", m.ID, "error", err)
+		}
+		return
+	}
+	if err := r.repo.Fail(record, m.ID, err.Error(), delivered); err != nil {
+		slog.ErrorContext(ctx, "Outbox relay error, record failure", "outbox_id", m.ID, "error", err)
+	}
+	if m.Attempts >= MaxAttempts {
+		metrics.OutboxDeadLetters.WithLabelValues(m.Topic).Inc()
+		slog.ErrorContext(ctx, "Outbox message dead-lettered", "outbox_id", m.ID, "topic", m.Topic, "attempts", m.Attempts, "error", err)
+		return
+	}
+	slog.WarnContext(ctx, "Outbox relay, publish failed", "outbox_id", m.ID, "topic", m.Topic, "attempts", m.Attempts, "error", err)
+}
+
+func DomainEvent(m models.OutboxMessage) models.DomainEvent {
+	return models.DomainEvent{
+		ID:          m.ID,
+		Topic:       m.Topic,
+		AggregateID: m.AggregateID,
+		Payload:     json.RawMessage(m.Payload),
+		OccurredAt:  m.CreatedAt,
+	}
+}
@@ -0,0 +1,52 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/outbox"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalBus_Publish(t *testing.T) {
+	bus := outbox.NewLocalBus()
+	var calls []string
+	bus.Subscribe(models.TopicBookCreated, "notify", func(ctx context.Context, e models.DomainEvent) error {
+		calls = append(calls, "created")
+		return errors.New("boom")
+	})
+	bus.Subscribe(models.TopicBookUpdated, "notify", func(ctx context.Context, e models.DomainEvent) error {
+		calls = append(calls, "updated")
+		return nil
+	})
+	bus.Subscribe(outbox.AllTopics, "stream", func(ctx context.Context, e models.DomainEvent) error {
+		calls = append(calls, "all")
+		return nil
+	})
+
+	delivered, err := bus.Publish(context.Background(), models.DomainEvent{ID: 1, Topic: models.TopicBookCreated}, nil)
+
+	// Ошибка одного обработчика не мешает остальным, но возвращается наружу
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, []string{"created", "all"}, calls)
+	assert.Equal(t, []string{"stream"}, delivered)
+
+	// Повтор вызывает только те обработчики, что не справились
+	calls = nil
+	delivered, err = bus.Publish(context.Background(), models.DomainEvent{ID: 1, Topic: models.TopicBookCreated}, delivered)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"created"}, calls)
+	assert.Equal(t, []string{"stream"}, delivered)
+}
+
+func TestLocalBus_SubscribeTwice(t *testing.T) {
+	bus := outbox.NewLocalBus()
+	handler := func(ctx context.Context, e models.DomainEvent) error { return nil }
+	bus.Subscribe(models.TopicBookCreated, "notify", handler)
+
+	// Имя обработчика определяет учёт доставки, поэтому оно уникально
+	assert.Panics(t, func() { bus.Subscribe(models.TopicBookCreated, "notify", handler) })
+}
@@ -0,0 +1,112 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/outbox"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryOutbox struct {
+	pending   []models.OutboxMessage
+	completed []uint
+	failed    map[uint]string
+	delivered map[uint][]string
+}
+
+func (r *memoryOutbox) Claim(ctx context.Context, limit int, maxAttempts uint, lease time.Duration) ([]models.OutboxMessage, error) {
+	var claimed []models.OutboxMessage
+	for len(r.pending) > 0 && len(claimed) < limit {
+		m := r.pending[0]
+		r.pending = r.pending[1:]
+		m.Attempts++
+		claimed = append(claimed, m)
+	}
+	return claimed, nil
+}
+
+func (r *memoryOutbox) Complete(ctx context.Context, id uint) error {
+	r.completed = append(r.completed, id)
+	return nil
+}
+
+func (r *memoryOutbox) Fail(ctx context.Context, id uint, reason string, delivered []string) error {
+	r.failed[id] = reason
+	r.delivered[id] = delivered
+	return nil
+}
+
+func TestRelay_Drain(t *testing.T) {
+	repo := &memoryOutbox{
+		pending: []models.OutboxMessage{
+			{ID: 1, Topic: models.TopicBookCreated},
+			{ID: 2, Topic: models.TopicBookUpdated, Attempts: outbox.MaxAttempts - 1},
+			{ID: 3, Topic: models.TopicBookDeleted},
+		},
+		failed:    map[uint]string{},
+		delivered: map[uint][]string{},
+	}
+	bus := outbox.NewLocalBus()
+	bus.Subscribe(models.TopicBookUpdated, "fail", func(ctx context.Context, e models.DomainEvent) error {
+		return errors.New("boom")
+	})
+
+	err := outbox.NewRelay(repo, bus, time.Second).Drain(context.Background())
+
+	// Неудачное сообщение не мешает следующим
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1, 3}, repo.completed)
+	assert.Contains(t, repo.failed[2], "boom")
+}
+
+func TestRelay_RecordsOutcomeAfterCancel(t *testing.T) {
+	repo := &memoryOutbox{pending: []models.OutboxMessage{{ID: 1, Topic: models.TopicBookCreated}}, failed: map[uint]string{}, delivered: map[uint][]string{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := outbox.NewLocalBus()
+	bus.Subscribe(models.TopicBookCreated, "cancel", func(ctx context.Context, e models.DomainEvent) error {
+		cancel()
+		return nil
+	})
+
+	err := outbox.NewRelay(repo, bus, time.Second).Drain(ctx)
+
+	// Остановка во время публикации не теряет результат
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1}, repo.completed)
+}
+
+func TestRelay_RetriesOnlyFailedHandlers(t *testing.T) {
+	repo := &memoryOutbox{
+		pending:   []models.OutboxMessage{{ID: 1, Topic: models.TopicBookCreated}},
+		failed:    map[uint]string{},
+		delivered: map[uint][]string{},
+	}
+	calls := map[string]int{}
+	bus := outbox.NewLocalBus()
+	bus.Subscribe(models.TopicBookCreated, "webhooks", func(ctx context.Context, e models.DomainEvent) error {
+		calls["webhooks"]++
+		return nil
+	})
+	bus.Subscribe(models.TopicBookCreated, "notifications", func(ctx context.Context, e models.DomainEvent) error {
+		calls["notifications"]++
+		if calls["notifications"] == 1 {
+			return errors.New("database error")
+		}
+		return nil
+	})
+	relay := outbox.NewRelay(repo, bus, time.Second)
+
+	assert.NoError(t, relay.Drain(context.Background()))
+	assert.Equal(t, []string{"webhooks"}, repo.delivered[1])
+
+	// Повторная попытка не вызывает вебхуки второй раз
+	repo.pending = []models.OutboxMessage{{ID: 1, Topic: models.TopicBookCreated, Attempts: 1, Delivered: repo.delivered[1]}}
+	assert.NoError(t, relay.Drain(context.Background()))
+	assert.Equal(t, map[string]int{"webhooks": 1, "notifications": 2}, calls)
+	assert.Equal(t, []uint{1}, repo.completed)
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Quavke/eBookReader/pkg/models"
@@ -9,11 +10,12 @@ import (
 )
 
 type AuthorRepo interface {
-    Create(author *models.Author) error
-    GetByID(id uint) (*models.Author, error)
-    GetAll(p *models.Pagination) (*models.Pagination, error)
-    Update(author *models.UpdateAuthorReq, id uint) error
-    Delete(id uint) error
+    Create(ctx context.Context, author *models.Author) error
+    GetByID(ctx context.Context, id uint) (*models.Author, error)
+    GetAll(ctx context.Context, p *models.Pagination) (*models.Pagination, error)
+    Update(ctx context.Context, author *models.UpdateAuthorReq, id uint, version uint) error
+    UpdateAvatar(ctx context.Context, id uint, avatarKey string) (string, error)
+    Delete(ctx context.Context, id uint) error
 }
 
 type GormAuthorRepo struct {
@@ -26,29 +28,30 @@ func NewGormAuthorRepo(db *gorm.DB) *GormAuthorRepo{
 	return &GormAuthorRepo{db: db}
 }
 
-func (r GormAuthorRepo) Create(author *models.Author) error{
-	result := r.db.Create(&author)
-	if result.RowsAffected == 0 {
-		return result.Error
-	}
-	return result.Error
+func (r GormAuthorRepo) Create(ctx context.Context, author *models.Author) error{
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(author).Error; err != nil {
+			return err
+		}
+		return enqueue(tx, models.TopicAuthorCreated, author.UserID, models.EntityEvent{ID: author.UserID, Version: author.Version})
+	})
 }
 
-func (r GormAuthorRepo) GetByID(id uint) (*models.Author, error){
+func (r GormAuthorRepo) GetByID(ctx context.Context, id uint) (*models.Author, error){
 	var author models.Author
-	result := r.db.Preload("Books").Where("user_id = ?", id).First(&author)
-	if result.RowsAffected == 0{
-		return nil, gorm.ErrRecordNotFound
-	}
+	result := r.db.WithContext(ctx).Where("user_id = ?", id).First(&author)
 	if err := result.Error; err != nil{
 		return nil, err
 	}
+	if result.RowsAffected == 0{
+		return nil, gorm.ErrRecordNotFound
+	}
 	return &author, nil
 }
 
-func (r GormAuthorRepo) GetAll(p *models.Pagination) (*models.Pagination, error){
+func (r GormAuthorRepo) GetAll(ctx context.Context, p *models.Pagination) (*models.Pagination, error){
 	var authors []models.Author
-	result := r.db.Scopes(models.Paginate(authors, p, r.db)).Find(&authors)
+	result := r.db.WithContext(ctx).Scopes(models.Paginate(authors, p, r.db.WithContext(ctx))).Find(&authors)
 
 	p.Rows = authors
 
@@ -61,15 +64,18 @@ func (r GormAuthorRepo) GetAll(p *models.Pagination) (*models.Pagination, error)
 	return p, nil
 }
 
-func (r GormAuthorRepo) Update(author *models.UpdateAuthorReq, id uint) error{
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r GormAuthorRepo) Update(ctx context.Context, author *models.UpdateAuthorReq, id uint, version uint) error{
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
         var existing models.Author
 				result := tx.Where("user_id = ?", id).First(&existing)
+        if err := result.Error; err != nil {
+            return err
+        }
 				if result.RowsAffected == 0{
 					return gorm.ErrRecordNotFound
 				}
-        if err := result.Error; err != nil {
-            return err
+        if existing.Version != version {
+            return ErrVersionMismatch
         }
 
 				
@@ -83,9 +89,32 @@ func (r GormAuthorRepo) Update(author *models.UpdateAuthorReq, id uint) error{
         if !author.Birthday.IsZero() && !author.Birthday.Equal(existing.Birthday.Time) {
             updates["birthday"] = author.Birthday
         }
+        if author.Bio != nil && *author.Bio != existing.Bio {
+            updates["bio"] = *author.Bio
+        }
+        if author.PenNames != nil {
+            updates["pen_names"] = author.PenNames
+        }
+        if author.Website != nil && *author.Website != existing.Website {
+            updates["website"] = *author.Website
+        }
+        if author.Links != nil {
+            updates["links"] = author.Links
+        }
+        if author.Country != nil && *author.Country != existing.Country {
+            updates["country"] = *author.Country
+        }
         
         if len(updates) > 0 {
-            return tx.Model(&existing).Updates(updates).Error
+            updates["version"] = version + 1
+            result = tx.Model(&existing).Where("version = ?", version).Updates(updates)
+            if err := result.Error; err != nil {
+                return err
+            }
+            if result.RowsAffected == 0 {
+                return ErrVersionMismatch
+            }
+            return enqueue(tx, models.TopicAuthorUpdated, id, models.EntityEvent{ID: id, Version: version + 1})
         }
 				if result.RowsAffected == 0{
 					return fmt.Errorf("no author found with id %d. Error: %v", id, result.Error)
@@ -94,11 +123,37 @@ func (r GormAuthorRepo) Update(author *models.UpdateAuthorReq, id uint) error{
     })
 }
 
-func (r GormAuthorRepo) Delete(id uint) error{
-	author := models.Author{UserID: uint(id)}
-	result := r.db.Select("Books").Delete(&author)
-	if result.RowsAffected == 0 {
-    return fmt.Errorf("no author found with id %d. Error: %v", id, result.Error)
-  }
-	return result.Error
+func (r GormAuthorRepo) UpdateAvatar(ctx context.Context, id uint, avatarKey string) (string, error){
+	var previous string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        var existing models.Author
+        result := tx.Select("user_id", "avatar_key").Where("user_id = ?", id).First(&existing)
+        if err := result.Error; err != nil {
+            return err
+        }
+        if result.RowsAffected == 0 {
+            return gorm.ErrRecordNotFound
+        }
+        previous = existing.AvatarKey
+
+        return tx.Model(&existing).Updates(map[string]interface{}{
+            "avatar_key": avatarKey,
+            "version":    gorm.Expr("version + 1"),
+        }).Error
+    })
+	return previous, err
+}
+
+func (r GormAuthorRepo) Delete(ctx context.Context, id uint) error{
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		author := models.Author{UserID: uint(id)}
+		result := tx.Select("Books").Delete(&author)
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("no author found with id %d. Error: %v", id, result.Error)
+		}
+		if err := result.Error; err != nil {
+			return err
+		}
+		return enqueue(tx, models.TopicAuthorDeleted, id, models.EntityEvent{ID: id})
+	})
 }
\ No newline at end of file
@@ -1,20 +1,28 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Quavke/eBookReader/pkg/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BookRepo interface {
-    Create(book *models.Book) error
-    GetByID(id uint) (*models.Book, error)
-    GetAll(p *models.Pagination) (*models.Pagination, error)
-    IsBelongsTo(id uint, authorID uint) (bool, error)
-    Update(book *models.Book, id uint) error
-    Delete(id uint) error
+    Create(ctx context.Context, book *models.Book) error
+    CreateImported(ctx context.Context, book *models.Book, jobID string) error
+    GetImported(ctx context.Context, jobID string) (*models.Book, error)
+    GetByID(ctx context.Context, id uint) (*models.Book, error)
+    GetWorkID(ctx context.Context, id uint) (*uint, error)
+    GetAll(ctx context.Context, p *models.Pagination, filter *models.BookFilter) (*models.Pagination, error)
+    GetByAuthor(ctx context.Context, authorID uint, p *models.Pagination) (*models.Pagination, error)
+    IsBelongsTo(ctx context.Context, id uint, authorID uint) (bool, error)
+    IsPrimaryAuthor(ctx context.Context, id uint, authorID uint) (bool, error)
+    Update(ctx context.Context, book *models.Book, id uint, version uint) error
+    UpdateCover(ctx context.Context, id uint, coverKey string) (string, error)
+    Delete(ctx context.Context, id uint) error
 }
 
 type GormBookRepo struct {
@@ -27,29 +35,74 @@ func NewGormBookRepo(db *gorm.DB) *GormBookRepo{
 	return &GormBookRepo{db: db}
 }
 
-func (r *GormBookRepo) Create(book *models.Book) error{
-    result := r.db.Create(book)
-    if result.RowsAffected == 0 {
-        return result.Error
+func (r *GormBookRepo) Create(ctx context.Context, book *models.Book) error{
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(book).Error; err != nil {
+            return err
+        }
+        return enqueue(tx, models.TopicBookCreated, book.ID, models.NewBookEvent(book))
+    })
+}
+
+// CreateImported creates book and records that jobID created it, in one
+// transaction, so a job that runs again can find the book with GetImported.
+func (r *GormBookRepo) CreateImported(ctx context.Context, book *models.Book, jobID string) error{
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(book).Error; err != nil {
+            return err
+        }
+        if err := tx.Create(&models.BookImport{JobID: jobID, BookID: book.ID}).Error; err != nil {
+            return err
+        }
+        return enqueue(tx, models.TopicBookCreated, book.ID, models.NewBookEvent(book))
+    })
+}
+
+// GetImported returns the book created by jobID, even if it was deleted
+// since, or gorm.ErrRecordNotFound if the job has not created one.
+func (r *GormBookRepo) GetImported(ctx context.Context, jobID string) (*models.Book, error) {
+    var book models.Book
+    err := r.db.WithContext(ctx).Unscoped().
+        Joins("JOIN book_imports ON book_imports.book_id = books.id").
+        Where("book_imports.job_id = ?", jobID).
+        First(&book).Error
+    if err != nil {
+        return nil, err
     }
-	return result.Error
+    return &book, nil
 }
 
-func (r *GormBookRepo) GetByID(id uint) (*models.Book, error) {
+func (r *GormBookRepo) GetByID(ctx context.Context, id uint) (*models.Book, error) {
 	var book models.Book
-    result := r.db.Where("id = ?", id).First(&book)
-    if result.RowsAffected == 0 {
-        return nil, gorm.ErrRecordNotFound
-    }
+    result := r.db.WithContext(ctx).Where("id = ?", id).First(&book)
+	// A cancelled or timed out query must not read as a missing book.
 	if err := result.Error; err != nil{
 		return nil, err
 	}
+    if result.RowsAffected == 0 {
+        return nil, gorm.ErrRecordNotFound
+    }
 	return &book, nil
 }
 
-func (r *GormBookRepo) IsBelongsTo(id uint, authorID uint) (bool, error){
+// GetWorkID returns the work the book is an edition of, or nil, without
+// loading its content.
+func (r *GormBookRepo) GetWorkID(ctx context.Context, id uint) (*uint, error) {
     var book models.Book
-    result := r.db.Where("id = ? AND author_id = ?", id, authorID).First(&book)
+    result := r.db.WithContext(ctx).Select("id", "work_id").Where("id = ?", id).First(&book)
+    if err := result.Error; err != nil {
+        return nil, err
+    }
+    return book.WorkID, nil
+}
+
+// IsBelongsTo reports whether authorID may edit the book: either as its
+// primary author or as a contributor who accepted the invitation.
+func (r *GormBookRepo) IsBelongsTo(ctx context.Context, id uint, authorID uint) (bool, error){
+    var book models.Book
+    result := r.db.WithContext(ctx).Select("id").
+        Where("id = ? AND (author_id = ? OR EXISTS (?))", id, authorID, acceptedContributor(r.db.WithContext(ctx), authorID)).
+        First(&book)
     if result.RowsAffected == 0 {
         return false, result.Error
     }
@@ -59,9 +112,25 @@ func (r *GormBookRepo) IsBelongsTo(id uint, authorID uint) (bool, error){
     return true, nil
 }
 
-func (r *GormBookRepo) GetAll(p *models.Pagination) (*models.Pagination, error){
+func (r *GormBookRepo) IsPrimaryAuthor(ctx context.Context, id uint, authorID uint) (bool, error){
+    var book models.Book
+    result := r.db.WithContext(ctx).Where("id = ? AND author_id = ?", id, authorID).First(&book)
+    if result.RowsAffected == 0 {
+        return false, result.Error
+    }
+    if err := result.Error; err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+func (r *GormBookRepo) GetAll(ctx context.Context, p *models.Pagination, filter *models.BookFilter) (*models.Pagination, error){
 	var books []models.Book
-    result := r.db.Scopes(models.Paginate(books, p, r.db)).Find(&books)
+    query := r.db.WithContext(ctx)
+    if filter != nil {
+        query = r.db.WithContext(ctx).Scopes(filter.Apply).Session(&gorm.Session{})
+    }
+    result := query.Scopes(models.Paginate(books, p, query)).Find(&books)
 
     p.Rows = books
 
@@ -74,34 +143,98 @@ func (r *GormBookRepo) GetAll(p *models.Pagination) (*models.Pagination, error){
 	return p, nil
 }
 
-func (r *GormBookRepo) Update(book *models.Book, id uint) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *GormBookRepo) GetByAuthor(ctx context.Context, authorID uint, p *models.Pagination) (*models.Pagination, error){
+	var books []models.Book
+    contributed := r.db.WithContext(ctx).Model(&models.BookContributor{}).Select("book_id").
+        Where("author_id = ? AND accepted_at IS NOT NULL", authorID)
+    query := r.db.WithContext(ctx).Where("author_id = ? OR id IN (?)", authorID, contributed).Session(&gorm.Session{})
+    result := query.Scopes(models.Paginate(books, p, query)).Find(&books)
+	if err := result.Error; err != nil{
+		return nil, err
+	}
+    p.Rows = books
+	return p, nil
+}
+
+func (r *GormBookRepo) Update(ctx context.Context, book *models.Book, id uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
         var existing models.Book
         result := tx.Where("id = ?", id).First(&existing)
+        if err := result.Error; err != nil {
+            return err
+        }
         if result.RowsAffected == 0 {
             return gorm.ErrRecordNotFound
         }
-        if err := result.Error; err != nil {
-            return err
+        if existing.Version != version {
+            return ErrVersionMismatch
         }
         updates := models.Book{
             Title:   book.Title,
             Content: book.Content,
+            Language: book.Language,
+            Version: version + 1,
+            TextStats: book.TextStats,
         }
 
-        result = tx.Model(&existing).Updates(updates)
+        result = tx.Model(&existing).Where("version = ?", version).Updates(updates)
+        if err := result.Error; err != nil {
+            return err
+        }
+        if result.RowsAffected == 0 {
+            return ErrVersionMismatch
+        }
+        return enqueue(tx, models.TopicBookUpdated, existing.ID, models.NewBookEvent(&existing))
+    })
+}
+
+func (r *GormBookRepo) UpdateCover(ctx context.Context, id uint, coverKey string) (string, error) {
+    var previous string
+    err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        var existing models.Book
+        result := tx.Select("id", "cover_key").Where("id = ?", id).First(&existing)
+        if err := result.Error; err != nil {
+            return err
+        }
+        if result.RowsAffected == 0 {
+            return gorm.ErrRecordNotFound
+        }
+        previous = existing.CoverKey
+
+        err := tx.Model(&existing).Updates(map[string]interface{}{
+            "cover_key": coverKey,
+            "version":   gorm.Expr("version + 1"),
+        }).Error
+        if err != nil {
+            return err
+        }
+        // Reload for the event: the version is bumped by the database.
+        var updated models.Book
+        if err := tx.Omit("content").Where("id = ?", id).First(&updated).Error; err != nil {
+            return err
+        }
+        return enqueue(tx, models.TopicBookUpdated, updated.ID, models.NewBookEvent(&updated))
+    })
+    return previous, err
+}
+
+// Delete returns the deleted row from the same statement so the outbox event
+// can name the book's author.
+func (r *GormBookRepo) Delete(ctx context.Context, id uint) error{
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        var book models.Book
+        result := tx.Clauses(clause.Returning{}).Where("id = ?", id).Delete(&book)
         if result.RowsAffected == 0 {
             return fmt.Errorf("no book found with id %d. Error: %v", id, result.Error)
         }
-        return result.Error
+        if err := result.Error; err != nil {
+            return err
+        }
+        return enqueue(tx, models.TopicBookDeleted, book.ID, models.NewBookEvent(&book))
     })
 }
 
-func (r *GormBookRepo) Delete(id uint) error{
-	var book models.Book
-	result := r.db.Where("id = ?", id).Delete(&book)
-	if result.RowsAffected == 0 {
-        return fmt.Errorf("no book found with id %d. Error: %v", id, result.Error)
-    }
-	return result.Error
-}
\ No newline at end of file
+func acceptedContributor(db *gorm.DB, authorID uint) *gorm.DB {
+    return db.Session(&gorm.Session{NewDB: true}).Model(&models.BookContributor{}).Select("1").
+        Where("book_contributors.book_id = books.id AND book_contributors.author_id = ? AND book_contributors.accepted_at IS NOT NULL", authorID)
+}
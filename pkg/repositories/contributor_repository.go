@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ContributorRepo interface {
+	Invite(ctx context.Context, contributor *models.BookContributor) error
+	Accept(ctx context.Context, bookID uint, authorID uint) error
+	Remove(ctx context.Context, bookID uint, authorID uint) error
+	ListByBook(ctx context.Context, bookID uint, acceptedOnly bool) ([]models.BookContributor, error)
+	ListInvitations(ctx context.Context, authorID uint) ([]models.BookContributor, error)
+}
+
+type GormContributorRepo struct {
+	db *gorm.DB
+}
+
+var _ ContributorRepo = (*GormContributorRepo)(nil)
+
+func NewGormContributorRepo(db *gorm.DB) *GormContributorRepo {
+	return &GormContributorRepo{db: db}
+}
+
+// Invite creates a pending invitation, or updates role and position of an
+// existing one without touching its acceptance.
+func (r *GormContributorRepo) Invite(ctx context.Context, contributor *models.BookContributor) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "book_id"}, {Name: "author_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "position", "updated_at"}),
+	}).Create(contributor).Error
+}
+
+// Accept and Remove change the book's contributor list, so they also bump
+// the book's version and updated_at, which its ETag and Last-Modified use.
+func (r *GormContributorRepo) Accept(ctx context.Context, bookID uint, authorID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BookContributor{}).
+			Where("book_id = ? AND author_id = ? AND accepted_at IS NULL", bookID, authorID).
+			Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return touchBook(tx, bookID)
+	})
+}
+
+func (r *GormContributorRepo) Remove(ctx context.Context, bookID uint, authorID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("book_id = ? AND author_id = ?", bookID, authorID).Delete(&models.BookContributor{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return touchBook(tx, bookID)
+	})
+}
+
+func touchBook(tx *gorm.DB, bookID uint) error {
+	return tx.Model(&models.Book{}).Where("id = ?", bookID).
+		Update("version", gorm.Expr("version + 1")).Error
+}
+
+func (r *GormContributorRepo) ListByBook(ctx context.Context, bookID uint, acceptedOnly bool) ([]models.BookContributor, error) {
+	var contributors []models.BookContributor
+	query := r.db.WithContext(ctx).Where("book_id = ?", bookID)
+	if acceptedOnly {
+		query = query.Where("accepted_at IS NOT NULL")
+	}
+	if err := query.Order("position, author_id").Find(&contributors).Error; err != nil {
+		return nil, err
+	}
+	return contributors, nil
+}
+
+func (r *GormContributorRepo) ListInvitations(ctx context.Context, authorID uint) ([]models.BookContributor, error) {
+	var contributors []models.BookContributor
+	err := r.db.WithContext(ctx).Where("author_id = ? AND accepted_at IS NULL", authorID).Order("created_at desc").Find(&contributors).Error
+	if err != nil {
+		return nil, err
+	}
+	return contributors, nil
+}
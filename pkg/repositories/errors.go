@@ -0,0 +1,5 @@
+package repositories
+
+import "errors"
+
+var ErrVersionMismatch = errors.New("resource was modified by another request")
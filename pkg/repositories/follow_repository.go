@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowRepo interface {
+	Follow(ctx context.Context, userID uint, authorID uint) error
+	Unfollow(ctx context.Context, userID uint, authorID uint) error
+	CountFollowers(ctx context.Context, authorIDs ...uint) (map[uint]int64, error)
+	Followers(ctx context.Context, authorID uint) ([]uint, error)
+	Feed(ctx context.Context, userID uint, cursor *models.FeedCursor, limit int) ([]models.Book, error)
+}
+
+type GormFollowRepo struct {
+	db *gorm.DB
+}
+
+var _ FollowRepo = (*GormFollowRepo)(nil)
+
+func NewGormFollowRepo(db *gorm.DB) *GormFollowRepo {
+	return &GormFollowRepo{db: db}
+}
+
+// Follow is idempotent: following an author twice is not an error.
+func (r *GormFollowRepo) Follow(ctx context.Context, userID uint, authorID uint) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Follow{UserID: userID, AuthorID: authorID}).Error
+}
+
+func (r *GormFollowRepo) Unfollow(ctx context.Context, userID uint, authorID uint) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND author_id = ?", userID, authorID).Delete(&models.Follow{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormFollowRepo) CountFollowers(ctx context.Context, authorIDs ...uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(authorIDs))
+	if len(authorIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		AuthorID uint
+		Count    int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.Follow{}).
+		Select("author_id, count(*) AS count").
+		Where("author_id IN ?", authorIDs).
+		Group("author_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.AuthorID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *GormFollowRepo) Followers(ctx context.Context, authorID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.Follow{}).Where("author_id = ?", authorID).Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Feed lists books of followed authors, newest first, starting strictly after
+// cursor. Keyset pagination keeps pages stable while new books arrive.
+func (r *GormFollowRepo) Feed(ctx context.Context, userID uint, cursor *models.FeedCursor, limit int) ([]models.Book, error) {
+	var books []models.Book
+	followed := r.db.WithContext(ctx).Model(&models.Follow{}).Select("author_id").Where("user_id = ?", userID)
+	query := r.db.WithContext(ctx).Omit("content").Where("author_id IN (?)", followed)
+	if cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+	err := query.Order("created_at desc, id desc").Limit(limit).Find(&books).Error
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepo interface {
+	Create(ctx context.Context, notifications []models.Notification) error
+	List(ctx context.Context, userID uint, unreadOnly bool, p *models.Pagination) (*models.Pagination, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	MarkRead(ctx context.Context, userID uint, id uint) error
+	MarkAllRead(ctx context.Context, userID uint) error
+	GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error)
+	SetPreferences(ctx context.Context, prefs []models.NotificationPreference) error
+	OptedOut(ctx context.Context, notificationType string, userIDs []uint) (map[uint]bool, error)
+}
+
+type GormNotificationRepo struct {
+	db *gorm.DB
+}
+
+var _ NotificationRepo = (*GormNotificationRepo)(nil)
+
+func NewGormNotificationRepo(db *gorm.DB) *GormNotificationRepo {
+	return &GormNotificationRepo{db: db}
+}
+
+func (r *GormNotificationRepo) Create(ctx context.Context, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(notifications, 500).Error
+}
+
+// List counts only the user's own notifications, which models.Paginate
+// cannot do since it counts the whole table.
+func (r *GormNotificationRepo) List(ctx context.Context, userID uint, unreadOnly bool, p *models.Pagination) (*models.Pagination, error) {
+	inbox := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", userID)
+		if unreadOnly {
+			db = db.Where("read_at IS NULL")
+		}
+		return db
+	}
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Notification{}).Scopes(inbox).Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var notifications []models.Notification
+	err := r.db.WithContext(ctx).Scopes(inbox).Offset(int(p.GetOffset())).Limit(int(p.GetLimit())).Order(p.GetSort()).Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+	p.TotalRows = uint64(total)
+	p.TotalPages = uint((total + int64(p.GetLimit()) - 1) / int64(p.GetLimit()))
+	p.Rows = notifications
+	return p, nil
+}
+
+func (r *GormNotificationRepo) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead is idempotent for notifications that are already read; it returns
+// gorm.ErrRecordNotFound only when the notification does not belong to the user.
+func (r *GormNotificationRepo) MarkRead(ctx context.Context, userID uint, id uint) error {
+	result := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormNotificationRepo) MarkAllRead(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error
+}
+
+func (r *GormNotificationRepo) GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func (r *GormNotificationRepo) SetPreferences(ctx context.Context, prefs []models.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&prefs).Error
+}
+
+// OptedOut returns the subset of userIDs that disabled notificationType.
+func (r *GormNotificationRepo) OptedOut(ctx context.Context, notificationType string, userIDs []uint) (map[uint]bool, error) {
+	out := make(map[uint]bool)
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.NotificationPreference{}).
+		Where("type = ? AND enabled = ? AND user_id IN ?", notificationType, false, userIDs).
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		out[id] = true
+	}
+	return out, nil
+}
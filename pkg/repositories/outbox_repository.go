@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"gorm.io/gorm"
+)
+
+type OutboxRepo interface {
+	Claim(ctx context.Context, limit int, maxAttempts uint, lease time.Duration) ([]models.OutboxMessage, error)
+	Complete(ctx context.Context, id uint) error
+	Fail(ctx context.Context, id uint, reason string, delivered []string) error
+}
+
+type GormOutboxRepo struct {
+	db *gorm.DB
+}
+
+var _ OutboxRepo = (*GormOutboxRepo)(nil)
+
+func NewGormOutboxRepo(db *gorm.DB) *GormOutboxRepo {
+	return &GormOutboxRepo{db: db}
+}
+
+const claimQuery = `UPDATE outbox SET attempts = attempts + 1, claimed_until = ?
+WHERE id IN (
+	SELECT id FROM outbox
+	WHERE dispatched_at IS NULL AND attempts < ? AND (claimed_until IS NULL OR claimed_until < ?)
+	ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED
+) RETURNING *`
+
+// Claim leases up to limit pending messages, oldest first, and counts the
+// attempt. It is a single statement, so no lock or connection is held while
+// the caller publishes. Rows claimed by another relay are skipped until their
+// lease runs out, which also recovers messages of a relay that died.
+func (r *GormOutboxRepo) Claim(ctx context.Context, limit int, maxAttempts uint, lease time.Duration) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	now := time.Now()
+	if err := r.db.WithContext(ctx).Raw(claimQuery, now.Add(lease), maxAttempts, now, limit).Scan(&messages).Error; err != nil {
+		return nil, err
+	}
+	// RETURNING does not keep the order of the subquery.
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	return messages, nil
+}
+
+// Complete marks a claimed message as dispatched.
+func (r *GormOutboxRepo) Complete(ctx context.Context, id uint) error {
+	return r.release(ctx, id, map[string]interface{}{"dispatched_at": time.Now(), "last_error": ""})
+}
+
+// Fail keeps reason and the subscribers that did handle the message, and
+// releases the claim, so the next Claim retries the rest unless the message
+// is out of attempts.
+func (r *GormOutboxRepo) Fail(ctx context.Context, id uint, reason string, delivered []string) error {
+	return r.release(ctx, id, map[string]interface{}{"last_error": reason, "delivered": models.StringList(delivered)})
+}
+
+func (r *GormOutboxRepo) release(ctx context.Context, id uint, updates map[string]interface{}) error {
+	updates["claimed_until"] = nil
+	return r.db.WithContext(ctx).Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// enqueue records an event inside tx, so it is stored if and only if the
+// change it describes is committed.
+func enqueue(tx *gorm.DB, topic string, aggregateID uint, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxMessage{Topic: topic, AggregateID: aggregateID, Payload: data}).Error
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProgressRepo interface {
+	Get(ctx context.Context, userID uint, bookID uint) (*models.ReadingProgress, error)
+	Save(ctx context.Context, progress *models.ReadingProgress) (bool, error)
+}
+
+type GormProgressRepo struct {
+	db *gorm.DB
+}
+
+var _ ProgressRepo = (*GormProgressRepo)(nil)
+
+func NewGormProgressRepo(db *gorm.DB) *GormProgressRepo {
+	return &GormProgressRepo{db: db}
+}
+
+func (r *GormProgressRepo) Get(ctx context.Context, userID uint, bookID uint) (*models.ReadingProgress, error) {
+	var progress models.ReadingProgress
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND book_id = ?", userID, bookID).First(&progress).Error; err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// Save stores progress only if it is newer than what is stored, so a device
+// that was offline cannot rewind a position recorded later elsewhere. It
+// reports whether the row was written.
+func (r *GormProgressRepo) Save(ctx context.Context, progress *models.ReadingProgress) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "book_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position", "page", "device_id", "client_updated_at", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: `"reading_progresses"."client_updated_at" < "excluded"."client_updated_at"`},
+		}},
+	}).Create(progress)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,38 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorRepo_Update_ClearsOptionalFields(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormAuthorRepo(gormDB)
+	empty := ""
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "authors" WHERE user_id = $1`)).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "firstname", "bio", "website", "country", "version"}).
+			AddRow(7, "Jane", "Novelist", "https://example.org", "GB", 3))
+	// Пустая строка очищает поле, отсутствующее поле не трогается
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "authors" SET "bio"=$1,"country"=$2,"version"=$3,"updated_at"=$4 WHERE version = $5 AND "authors"."deleted_at" IS NULL AND "user_id" = $6`)).
+		WithArgs("", "", 4, sqlmock.AnyArg(), 3, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(insertOutboxQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err := repo.Update(context.Background(), &models.UpdateAuthorReq{Bio: &empty, Country: &empty}, 7, 3)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
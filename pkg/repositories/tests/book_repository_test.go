@@ -1,6 +1,7 @@
 package repositories_test
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"testing"
@@ -15,6 +16,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// INSERT, который GORM генерирует для models.Book
+var insertBookQuery = regexp.QuoteMeta(`INSERT INTO "books" ("created_at","updated_at","deleted_at","title","content","author_id","version","word_count","char_count","paragraph_count","reading_minutes","readability","cover_key","language","work_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15) RETURNING "id"`)
+
+// INSERT события в outbox, который пишется в той же транзакции
+var insertOutboxQuery = regexp.QuoteMeta(`INSERT INTO "outbox" ("topic","aggregate_id","payload","attempts","last_error","dispatched_at","claimed_until","delivered","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`)
+
 // Вспомогательная функция для создания mock базы данных
 func setupTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, func()) {
 	db, mock, err := sqlmock.New()
@@ -77,7 +84,7 @@ func TestBookRepo_GetAll(t *testing.T) {
 	mock.ExpectQuery(query).WillReturnRows(rows)
 
 	pag := &models.Pagination{Limit: 10, Page: 1, Sort: "title"}
-	result, err := repo.GetAll(pag)
+	result, err := repo.GetAll(context.Background(), pag, nil)
 	
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -102,7 +109,7 @@ func TestBookRepo_GetAll(t *testing.T) {
 	}))
 	
 	pagEmpty := &models.Pagination{Limit: 10, Page: 2, Sort: "title"}
-	resultEmpty, err := repo.GetAll(pagEmpty)
+	resultEmpty, err := repo.GetAll(context.Background(), pagEmpty, nil)
 	
 	assert.Error(t, err)
 	assert.Nil(t, resultEmpty)
@@ -111,6 +118,68 @@ func TestBookRepo_GetAll(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestBookRepo_GetAll_WithFilter(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	// Фильтр применяется и к COUNT, и к выборке
+	countQuery := regexp.QuoteMeta(`SELECT count(*) FROM "books" WHERE word_count >= $1 AND reading_minutes <= $2 AND "books"."deleted_at" IS NULL`)
+	mock.ExpectQuery(countQuery).WithArgs(1000, 30).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	query := regexp.QuoteMeta(`SELECT * FROM "books" WHERE word_count >= $1 AND reading_minutes <= $2 AND "books"."deleted_at" IS NULL ORDER BY word_count desc, id desc LIMIT $3`)
+	rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id", "word_count", "reading_minutes"}).
+		AddRow(7, "Long book", "Long content", 1, 5000, 22)
+	mock.ExpectQuery(query).WithArgs(1000, 30, 10).WillReturnRows(rows)
+
+	pag := &models.Pagination{Limit: 10, Page: 1, Sort: "word_count desc, id desc"}
+	result, err := repo.GetAll(context.Background(), pag, &models.BookFilter{MinWords: 1000, MaxMinutes: 30})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), result.TotalRows)
+	books := result.Rows.([]models.Book)
+	assert.Len(t, books, 1)
+	assert.Equal(t, 5000, books[0].WordCount)
+	assert.Equal(t, 22, books[0].ReadingMinutes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookRepo_GetByAuthor(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	// Книги автора включают и те, где он принял приглашение соавтора
+	countQuery := regexp.QuoteMeta(`SELECT count(*) FROM "books" WHERE (author_id = $1 OR id IN (SELECT "book_id" FROM "book_contributors" WHERE author_id = $2 AND accepted_at IS NOT NULL)) AND "books"."deleted_at" IS NULL`)
+	mock.ExpectQuery(countQuery).WithArgs(5, 5).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	query := regexp.QuoteMeta(`SELECT * FROM "books" WHERE (author_id = $1 OR id IN (SELECT "book_id" FROM "book_contributors" WHERE author_id = $2 AND accepted_at IS NOT NULL)) AND "books"."deleted_at" IS NULL ORDER BY id desc LIMIT $3`)
+	rows := sqlmock.NewRows([]string{"id", "title", "content", "author_id"}).
+		AddRow(3, "Author book", "Some content", 5)
+	mock.ExpectQuery(query).WithArgs(5, 5, 20).WillReturnRows(rows)
+
+	result, err := repo.GetByAuthor(context.Background(), 5, &models.Pagination{Limit: 20, Page: 1, Sort: "id desc"})
+
+	assert.NoError(t, err)
+	books := result.Rows.([]models.Book)
+	assert.Len(t, books, 1)
+	assert.Equal(t, uint(5), books[0].AuthorID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// У автора без книг должен быть пустой список, а не ошибка
+	mock.ExpectQuery(countQuery).WithArgs(6, 6).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(query).WithArgs(6, 6, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "author_id"}))
+
+	empty, err := repo.GetByAuthor(context.Background(), 6, &models.Pagination{Limit: 20, Page: 1, Sort: "id desc"})
+
+	assert.NoError(t, err)
+	assert.Len(t, empty.Rows.([]models.Book), 0)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestBookRepo_GetAll_InvalidPagination(t *testing.T) {
 	// Тест с некорректными параметрами пагинации
 	invalidPag := &models.Pagination{Limit: 0, Page: 0, Sort: ""}
@@ -154,7 +223,7 @@ func TestBookRepo_GetByID(t *testing.T) {
 
 	mock.ExpectQuery(query).WithArgs(1, 1).WillReturnRows(row)
 
-	book, err := repo.GetByID(1)
+	book, err := repo.GetByID(context.Background(), 1)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, book)
@@ -168,7 +237,7 @@ func TestBookRepo_GetByID(t *testing.T) {
 	// Тест с некорректными данными: книга не найдена
 	mock.ExpectQuery(query).WithArgs(999, 1).WillReturnError(gorm.ErrRecordNotFound)
 
-	bookNotFound, err := repo.GetByID(999)
+	bookNotFound, err := repo.GetByID(context.Background(), 999)
 
 	assert.Error(t, err)
 	assert.Nil(t, bookNotFound)
@@ -184,11 +253,36 @@ func TestBookRepo_GetByID_InvalidID(t *testing.T) {
 	repo := repositories.NewGormBookRepo(gormDB)
 
 	// Тест с некорректным ID: 0
-	book, err := repo.GetByID(0)
+	book, err := repo.GetByID(context.Background(), 0)
 	assert.Error(t, err)
 	assert.Nil(t, book)
 }
 
+func TestBookRepo_GetWorkID(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	// Содержимое книги не загружается
+	query := regexp.QuoteMeta(`SELECT "id","work_id" FROM "books" WHERE id = $1 AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $2`)
+	mock.ExpectQuery(query).WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "work_id"}).AddRow(1, 5))
+
+	workID, err := repo.GetWorkID(context.Background(), 1)
+	assert.NoError(t, err)
+	if assert.NotNil(t, workID) {
+		assert.Equal(t, uint(5), *workID)
+	}
+
+	// Книга не входит ни в одну работу
+	mock.ExpectQuery(query).WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "work_id"}).AddRow(2, nil))
+
+	workID, err = repo.GetWorkID(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Nil(t, workID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestBookRepo_Create(t *testing.T) {
 	gormDB, mock, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -204,12 +298,15 @@ func TestBookRepo_Create(t *testing.T) {
 	// Тест успешного создания книги
 	mock.ExpectBegin()
 	
-	query := regexp.QuoteMeta(`INSERT INTO "books" ("created_at","updated_at","deleted_at","title","content","author_id") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)
+	query := insertBookQuery
 
 	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(insertOutboxQuery).
+		WithArgs(models.TopicBookCreated, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
-	err := repo.Create(testBook)
+	err := repo.Create(context.Background(), testBook)
 
 	assert.NoError(t, err)
 	assert.Equal(t, uint(1), testBook.ID)
@@ -226,7 +323,7 @@ func TestBookRepo_Create(t *testing.T) {
 	mock.ExpectQuery(query).WillReturnError(gorm.ErrInvalidData)
 	mock.ExpectRollback()
 
-	err = repo.Create(invalidBook)
+	err = repo.Create(context.Background(), invalidBook)
 	assert.Error(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -245,15 +342,47 @@ func TestBookRepo_Create_InvalidData(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	query := regexp.QuoteMeta(`INSERT INTO "books" ("created_at","updated_at","deleted_at","title","content","author_id") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)
+	query := insertBookQuery
 	mock.ExpectQuery(query).WillReturnError(gorm.ErrInvalidData)
 	mock.ExpectRollback()
 
-	err := repo.Create(invalidBook)
+	err := repo.Create(context.Background(), invalidBook)
 	assert.Error(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestBookRepo_CreateImported(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormBookRepo(gormDB)
+	book := &models.Book{Title: "Emma", Content: "Emma Woodhouse, handsome, clever, and rich.", AuthorID: 7}
+
+	// Книга и отметка об импорте создаются в одной транзакции
+	mock.ExpectBegin()
+	mock.ExpectQuery(insertBookQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "book_imports" ("job_id","book_id","created_at") VALUES ($1,$2,$3)`)).
+		WithArgs("job-1", 3, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(insertOutboxQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.CreateImported(context.Background(), book, "job-1"))
+	assert.Equal(t, uint(3), book.ID)
+
+	// Повтор задачи находит уже созданную книгу
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "books"."id",`) + `.*` + regexp.QuoteMeta(`JOIN book_imports ON book_imports.book_id = books.id WHERE book_imports.job_id = $1`)).
+		WithArgs("job-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id"}).AddRow(3, "Emma", 7))
+	found, err := repo.GetImported(context.Background(), "job-1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), found.ID)
+
+	mock.ExpectQuery(`JOIN book_imports`).WithArgs("job-2", 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = repo.GetImported(context.Background(), "job-2")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestBookRepo_Update(t *testing.T) {
 	gormDB, mock, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -266,6 +395,7 @@ func TestBookRepo_Update(t *testing.T) {
 		Title:    "The Go Programming Language",
 		Content:  "Original content",
 		AuthorID: 123,
+		Version:  1,
 	}
 	
 	updatedBook := &models.Book{
@@ -279,7 +409,7 @@ func TestBookRepo_Update(t *testing.T) {
 	query := regexp.QuoteMeta(`SELECT * FROM "books" WHERE id = $1 AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $2`)
 	
 	row := sqlmock.NewRows([]string{
-		"id", "created_at", "updated_at", "deleted_at", "title", "content", "author_id",
+		"id", "created_at", "updated_at", "deleted_at", "title", "content", "author_id", "version",
 	})
 	
 	row.AddRow(
@@ -290,24 +420,31 @@ func TestBookRepo_Update(t *testing.T) {
 		existingBook.Title,
 		existingBook.Content,
 		existingBook.AuthorID,
+		existingBook.Version,
 	)
 
 	mock.ExpectQuery(query).WithArgs(1, 1).WillReturnRows(row)
 
 	// GORM генерирует UPDATE с другим порядком полей и добавляет deleted_at IS NULL
-	updateQuery := regexp.QuoteMeta(`UPDATE "books" SET "updated_at"=$1,"title"=$2,"content"=$3 WHERE "books"."deleted_at" IS NULL AND "id" = $4`)
+	updateQuery := regexp.QuoteMeta(`UPDATE "books" SET "updated_at"=$1,"title"=$2,"content"=$3,"version"=$4 WHERE version = $5 AND "books"."deleted_at" IS NULL AND "id" = $6`)
 	mock.ExpectExec(updateQuery).
 		WithArgs(
 			sqlmock.AnyArg(), // updated_at
 			updatedBook.Title,
 			updatedBook.Content,
+			2, // новая версия
+			1, // ожидаемая версия
 			1,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	// Событие book.updated записывается в той же транзакции
+	mock.ExpectQuery(insertOutboxQuery).
+		WithArgs(models.TopicBookUpdated, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 	
-	err := repo.Update(updatedBook, 1)
+	err := repo.Update(context.Background(), updatedBook, 1, 1)
 	
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -317,7 +454,7 @@ func TestBookRepo_Update(t *testing.T) {
 	mock.ExpectQuery(query).WithArgs(999, 1).WillReturnError(gorm.ErrRecordNotFound)
 	mock.ExpectRollback()
 
-	err = repo.Update(updatedBook, 999)
+	err = repo.Update(context.Background(), updatedBook, 999, 1)
 	assert.Error(t, err)
 	assert.Equal(t, gorm.ErrRecordNotFound, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -334,6 +471,7 @@ func TestBookRepo_Update_InvalidData(t *testing.T) {
 		Title:    "Existing Book",
 		Content:  "Existing content",
 		AuthorID: 123,
+		Version:  1,
 	}
 
 	// Тест с некорректными данными: пустой заголовок
@@ -346,7 +484,7 @@ func TestBookRepo_Update_InvalidData(t *testing.T) {
 	query := regexp.QuoteMeta(`SELECT * FROM "books" WHERE id = $1 AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $2`)
 	
 	row := sqlmock.NewRows([]string{
-		"id", "created_at", "updated_at", "deleted_at", "title", "content", "author_id",
+		"id", "created_at", "updated_at", "deleted_at", "title", "content", "author_id", "version",
 	})
 	
 	row.AddRow(
@@ -357,27 +495,95 @@ func TestBookRepo_Update_InvalidData(t *testing.T) {
 		existingBook.Title,
 		existingBook.Content,
 		existingBook.AuthorID,
+		existingBook.Version,
 	)
 
 	mock.ExpectQuery(query).WithArgs(1, 1).WillReturnRows(row)
 
 	// GORM генерирует UPDATE только для измененных полей
-	updateQuery := regexp.QuoteMeta(`UPDATE "books" SET "updated_at"=$1,"content"=$2 WHERE "books"."deleted_at" IS NULL AND "id" = $3`)
+	updateQuery := regexp.QuoteMeta(`UPDATE "books" SET "updated_at"=$1,"content"=$2,"version"=$3 WHERE version = $4 AND "books"."deleted_at" IS NULL AND "id" = $5`)
 	mock.ExpectExec(updateQuery).
 		WithArgs(
 			sqlmock.AnyArg(), // updated_at
 			invalidBook.Content,
+			2,
+			1,
 			1,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
+	mock.ExpectQuery(insertOutboxQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 	
-	err := repo.Update(invalidBook, 1)
+	err := repo.Update(context.Background(), invalidBook, 1, 1)
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestBookRepo_Update_VersionMismatch(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	now := time.Now().UTC()
+	query := regexp.QuoteMeta(`SELECT * FROM "books" WHERE id = $1 AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $2`)
+	columns := []string{"id", "created_at", "updated_at", "deleted_at", "title", "content", "author_id", "version"}
+	updatedBook := &models.Book{Title: "New title", Content: "New content for the book"}
+
+	// Тест с устаревшей версией: книга уже обновлена другим запросом
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, now, now, nil, "Old title", "Old content", 123, 3))
+	mock.ExpectRollback()
+
+	err := repo.Update(context.Background(), updatedBook, 1, 2)
+	assert.ErrorIs(t, err, repositories.ErrVersionMismatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Тест гонки: версия совпала при чтении, но UPDATE не затронул ни одной строки
+	mock.ExpectBegin()
+	mock.ExpectQuery(query).WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, now, now, nil, "Old title", "Old content", 123, 2))
+	updateQuery := regexp.QuoteMeta(`UPDATE "books" SET "updated_at"=$1,"title"=$2,"content"=$3,"version"=$4 WHERE version = $5 AND "books"."deleted_at" IS NULL AND "id" = $6`)
+	mock.ExpectExec(updateQuery).
+		WithArgs(sqlmock.AnyArg(), updatedBook.Title, updatedBook.Content, 3, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.Update(context.Background(), updatedBook, 1, 2)
+	assert.ErrorIs(t, err, repositories.ErrVersionMismatch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookRepo_UpdateCover(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","cover_key" FROM "books" WHERE id = $1`)).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "cover_key"}).AddRow(1, "covers/1/old"))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "books" SET "cover_key"=$1,"version"=version + 1,"updated_at"=$2 WHERE "books"."deleted_at" IS NULL AND "id" = $3`)).
+		WithArgs("covers/1/new", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Событие строится по перечитанной строке, без содержимого книги
+	mock.ExpectQuery(`SELECT "books"\."id",.*"books"\."cover_key".* FROM "books" WHERE id = \$1`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "version", "cover_key"}).AddRow(1, "Emma", 7, 3, "covers/1/new"))
+	mock.ExpectQuery(insertOutboxQuery).
+		WithArgs("book.updated", 1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	previous, err := repo.UpdateCover(context.Background(), 1, "covers/1/new")
+	assert.NoError(t, err)
+	assert.Equal(t, "covers/1/old", previous)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestBookRepo_Delete(t *testing.T) {
 	gormDB, mock, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -386,21 +592,26 @@ func TestBookRepo_Delete(t *testing.T) {
 
 	// Тест успешного удаления книги
 	// GORM использует soft delete - UPDATE вместо DELETE и может добавлять транзакции
+	// RETURNING отдаёт удалённую строку, чтобы событие знало автора книги
 	mock.ExpectBegin()
-	query := regexp.QuoteMeta(`UPDATE "books" SET "deleted_at"=$1 WHERE id = $2 AND "books"."deleted_at" IS NULL`)
-	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	query := regexp.QuoteMeta(`UPDATE "books" SET "deleted_at"=$1 WHERE id = $2 AND "books"."deleted_at" IS NULL RETURNING *`)
+	mock.ExpectQuery(query).WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id"}).AddRow(1, "Deleted book", 123))
+	mock.ExpectQuery(insertOutboxQuery).
+		WithArgs(models.TopicBookDeleted, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
-	err := repo.Delete(1)
+	err := repo.Delete(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 
 	// Тест с некорректными данными: книга не найдена
 	mock.ExpectBegin()
-	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 999).WillReturnResult(sqlmock.NewResult(0, 0))
-	mock.ExpectCommit()
+	mock.ExpectQuery(query).WithArgs(sqlmock.AnyArg(), 999).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
 
-	err = repo.Delete(999)
+	err = repo.Delete(context.Background(), 999)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no book found with id 999")
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -413,7 +624,7 @@ func TestBookRepo_Delete_InvalidID(t *testing.T) {
 	repo := repositories.NewGormBookRepo(gormDB)
 
 	// Тест с некорректным ID: 0
-	err := repo.Delete(0)
+	err := repo.Delete(context.Background(), 0)
 	assert.Error(t, err)
 }
 
@@ -431,7 +642,62 @@ func TestBookRepo_IsBelongsTo(t *testing.T) {
 		AuthorID: 123,
 	}
 
-	// Тест успешной проверки принадлежности книги автору
+	// Книга принадлежит основному автору или соавтору, принявшему приглашение
+	query := regexp.QuoteMeta(`SELECT "id" FROM "books" WHERE (id = $1 AND (author_id = $2 OR EXISTS (SELECT 1 FROM "book_contributors" WHERE book_contributors.book_id = books.id AND book_contributors.author_id = $3 AND book_contributors.accepted_at IS NOT NULL))) AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $4`)
+	
+	row := sqlmock.NewRows([]string{"id"}).AddRow(testBook.ID)
+
+	mock.ExpectQuery(query).WithArgs(1, 123, 123, 1).WillReturnRows(row)
+
+	belongs, err := repo.IsBelongsTo(context.Background(), 1, 123)
+	
+	assert.NoError(t, err)
+	assert.True(t, belongs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Тест с некорректными данными: книга не принадлежит автору
+	// Когда книга не найдена, GORM возвращает ошибку, а не пустой результат
+	mock.ExpectQuery(query).WithArgs(1, 999, 999, 1).WillReturnError(gorm.ErrRecordNotFound)
+
+	belongs, err = repo.IsBelongsTo(context.Background(), 1, 999)
+	
+	assert.Error(t, err)
+	assert.False(t, belongs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookRepo_IsBelongsTo_InvalidData(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	// Тест с некорректными данными: несуществующий ID книги
+	query := regexp.QuoteMeta(`SELECT "id" FROM "books" WHERE (id = $1 AND (author_id = $2 OR EXISTS (SELECT 1 FROM "book_contributors" WHERE book_contributors.book_id = books.id AND book_contributors.author_id = $3 AND book_contributors.accepted_at IS NOT NULL))) AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $4`)
+	mock.ExpectQuery(query).WithArgs(999, 123, 123, 1).WillReturnError(gorm.ErrRecordNotFound)
+
+	belongs, err := repo.IsBelongsTo(context.Background(), 999, 123)
+	
+	assert.Error(t, err)
+	assert.False(t, belongs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookRepo_IsPrimaryAuthor(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	now := time.Now().UTC()
+	testBook := models.Book{
+		Model: gorm.Model{ID: 1, CreatedAt: now, UpdatedAt: now},
+		Title:    "Test Book",
+		Content:  "Test content",
+		AuthorID: 123,
+	}
+
+	// Тест успешной проверки основного автора книги
 	query := regexp.QuoteMeta(`SELECT * FROM "books" WHERE (id = $1 AND author_id = $2) AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $3`)
 	
 	row := sqlmock.NewRows([]string{
@@ -450,7 +716,7 @@ func TestBookRepo_IsBelongsTo(t *testing.T) {
 
 	mock.ExpectQuery(query).WithArgs(1, 123, 1).WillReturnRows(row)
 
-	belongs, err := repo.IsBelongsTo(1, 123)
+	belongs, err := repo.IsPrimaryAuthor(context.Background(), 1, 123)
 	
 	assert.NoError(t, err)
 	assert.True(t, belongs)
@@ -460,14 +726,14 @@ func TestBookRepo_IsBelongsTo(t *testing.T) {
 	// Когда книга не найдена, GORM возвращает ошибку, а не пустой результат
 	mock.ExpectQuery(query).WithArgs(1, 999, 1).WillReturnError(gorm.ErrRecordNotFound)
 
-	belongs, err = repo.IsBelongsTo(1, 999)
+	belongs, err = repo.IsPrimaryAuthor(context.Background(), 1, 999)
 	
 	assert.Error(t, err)
 	assert.False(t, belongs)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestBookRepo_IsBelongsTo_InvalidData(t *testing.T) {
+func TestBookRepo_IsPrimaryAuthor_InvalidData(t *testing.T) {
 	gormDB, mock, cleanup := setupTestDB(t)
 	defer cleanup()
 
@@ -477,7 +743,7 @@ func TestBookRepo_IsBelongsTo_InvalidData(t *testing.T) {
 	query := regexp.QuoteMeta(`SELECT * FROM "books" WHERE (id = $1 AND author_id = $2) AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $3`)
 	mock.ExpectQuery(query).WithArgs(999, 123, 1).WillReturnError(gorm.ErrRecordNotFound)
 
-	belongs, err := repo.IsBelongsTo(999, 123)
+	belongs, err := repo.IsPrimaryAuthor(context.Background(), 999, 123)
 	
 	assert.Error(t, err)
 	assert.False(t, belongs)
@@ -499,11 +765,12 @@ func TestBookRepo_EdgeCases(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	query := regexp.QuoteMeta(`INSERT INTO "books" ("created_at","updated_at","deleted_at","title","content","author_id") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)
+	query := insertBookQuery
 	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(insertOutboxQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
-	err := repo.Create(maxBook)
+	err := repo.Create(context.Background(), maxBook)
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 
@@ -516,9 +783,10 @@ func TestBookRepo_EdgeCases(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery(insertOutboxQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
 	mock.ExpectCommit()
 
-	err = repo.Create(minBook)
+	err = repo.Create(context.Background(), minBook)
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -557,7 +825,7 @@ func TestBookRepo_Performance(t *testing.T) {
 	
 	mock.ExpectQuery(query).WillReturnRows(rows)
 
-	result, err := repo.GetAll(largePagination)
+	result, err := repo.GetAll(context.Background(), largePagination, nil)
 	
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
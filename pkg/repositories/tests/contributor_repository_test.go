@@ -0,0 +1,90 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var touchBookQuery = regexp.QuoteMeta(`UPDATE "books" SET "version"=version + 1,"updated_at"=$1 WHERE id = $2 AND "books"."deleted_at" IS NULL`)
+
+func TestContributorRepo_Accept(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormContributorRepo(gormDB)
+
+	// Принять можно только ещё не принятое приглашение
+	query := regexp.QuoteMeta(`UPDATE "book_contributors" SET "accepted_at"=$1,"updated_at"=$2 WHERE book_id = $3 AND author_id = $4 AND accepted_at IS NULL`)
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Список соавторов входит в ответ книги, поэтому меняется её версия
+	mock.ExpectExec(touchBookQuery).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Accept(context.Background(), 1, 7)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Повторное принятие или отсутствие приглашения
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 8).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.Accept(context.Background(), 1, 8)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContributorRepo_Remove(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormContributorRepo(gormDB)
+
+	query := regexp.QuoteMeta(`DELETE FROM "book_contributors" WHERE book_id = $1 AND author_id = $2`)
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(1, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(touchBookQuery).WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Remove(context.Background(), 1, 7)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Нечего удалять — версия книги не меняется
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(1, 8).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.Remove(context.Background(), 1, 8)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContributorRepo_ListByBook(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormContributorRepo(gormDB)
+
+	query := regexp.QuoteMeta(`SELECT * FROM "book_contributors" WHERE book_id = $1 AND accepted_at IS NOT NULL ORDER BY position, author_id`)
+	rows := sqlmock.NewRows([]string{"book_id", "author_id", "role", "position"}).
+		AddRow(1, 7, "translator", 1).
+		AddRow(1, 9, "illustrator", 2)
+	mock.ExpectQuery(query).WithArgs(1).WillReturnRows(rows)
+
+	contributors, err := repo.ListByBook(context.Background(), 1, true)
+
+	assert.NoError(t, err)
+	assert.Len(t, contributors, 2)
+	assert.Equal(t, "translator", contributors[0].Role)
+	assert.Equal(t, uint(9), contributors[1].AuthorID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,68 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowRepo_Feed(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormFollowRepo(gormDB)
+	now := time.Now().UTC()
+
+	// Первая страница: без курсора, текст книг не загружается
+	query := regexp.QuoteMeta(`SELECT "books"."id","books"."created_at","books"."updated_at","books"."deleted_at","books"."title","books"."author_id",`)
+	mock.ExpectQuery(query).WithArgs(7, 3).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "created_at", "title", "author_id"}).
+			AddRow(5, now, "Newest", 2).
+			AddRow(4, now.Add(-time.Hour), "Older", 3),
+	)
+
+	books, err := repo.Feed(context.Background(), 7, nil, 3)
+
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+	assert.Equal(t, uint(5), books[0].ID)
+	assert.Empty(t, books[0].Content)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Следующая страница начинается строго после курсора
+	cursorQuery := regexp.QuoteMeta(`WHERE author_id IN (SELECT "author_id" FROM "follows" WHERE user_id = $1) AND (created_at, id) < ($2, $3) AND "books"."deleted_at" IS NULL ORDER BY created_at desc, id desc LIMIT $4`)
+	mock.ExpectQuery(cursorQuery).WithArgs(7, now, 4, 3).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	books, err = repo.Feed(context.Background(), 7, &models.FeedCursor{CreatedAt: now, ID: 4}, 3)
+
+	assert.NoError(t, err)
+	assert.Empty(t, books)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowRepo_CountFollowers(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormFollowRepo(gormDB)
+
+	query := regexp.QuoteMeta(`SELECT author_id, count(*) AS count FROM "follows" WHERE author_id IN ($1,$2) GROUP BY "author_id"`)
+	mock.ExpectQuery(query).WithArgs(1, 2).WillReturnRows(
+		sqlmock.NewRows([]string{"author_id", "count"}).AddRow(1, 42),
+	)
+
+	counts, err := repo.CountFollowers(context.Background(), 1, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), counts[1])
+	// У автора без подписчиков нет строки, счётчик равен нулю
+	assert.Equal(t, int64(0), counts[2])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
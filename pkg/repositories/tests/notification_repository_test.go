@@ -0,0 +1,56 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNotificationRepo_OptedOut(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormNotificationRepo(gormDB)
+
+	query := regexp.QuoteMeta(`SELECT "user_id" FROM "notification_preferences" WHERE type = $1 AND enabled = $2 AND user_id IN ($3,$4,$5)`)
+	mock.ExpectQuery(query).WithArgs("new_book", false, 1, 2, 3).WillReturnRows(
+		sqlmock.NewRows([]string{"user_id"}).AddRow(2),
+	)
+
+	optedOut, err := repo.OptedOut(context.Background(), "new_book", []uint{1, 2, 3})
+
+	assert.NoError(t, err)
+	assert.True(t, optedOut[2])
+	// Пользователи без явной настройки получают уведомления
+	assert.False(t, optedOut[1])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationRepo_MarkRead(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormNotificationRepo(gormDB)
+
+	query := regexp.QuoteMeta(`UPDATE "notifications" SET "read_at"=COALESCE(read_at, $1) WHERE id = $2 AND user_id = $3`)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 5, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.MarkRead(context.Background(), 7, 5))
+
+	// Чужое уведомление не найдено
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), 5, 8).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	assert.ErrorIs(t, repo.MarkRead(context.Background(), 8, 5), gorm.ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,68 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRepo_Claim(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormOutboxRepo(gormDB)
+
+	// Захват одним запросом, без открытой транзакции на время публикации
+	claimQuery := regexp.QuoteMeta(`UPDATE outbox SET attempts = attempts + 1, claimed_until = $1`) +
+		`(.|\n)*` + regexp.QuoteMeta(`FOR UPDATE SKIP LOCKED`) + `(.|\n)*` + regexp.QuoteMeta(`RETURNING *`)
+	mock.ExpectQuery(claimQuery).
+		WithArgs(sqlmock.AnyArg(), 10, sqlmock.AnyArg(), 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "topic", "aggregate_id", "payload", "attempts"}).
+			AddRow(2, models.TopicBookUpdated, 5, `{"id":5}`, 4).
+			AddRow(1, models.TopicBookCreated, 5, `{"id":5}`, 1))
+
+	messages, err := repo.Claim(context.Background(), 50, 10, time.Minute)
+
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, uint(1), messages[0].ID)
+		assert.Equal(t, uint(4), messages[1].Attempts)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepo_CompleteAndFail(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormOutboxRepo(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox" SET "claimed_until"=$1,"dispatched_at"=$2,"last_error"=$3 WHERE id = $4`)).
+		WithArgs(nil, sqlmock.AnyArg(), "", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.Complete(context.Background(), 1))
+
+	// Ошибка и успевшие обработчики сохраняются, а захват снимается
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox" SET "claimed_until"=$1,"delivered"=$2,"last_error"=$3 WHERE id = $4`)).
+		WithArgs(nil, `["webhooks"]`, "subscriber failed", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.Fail(context.Background(), 2, "subscriber failed", []string{"webhooks"}))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox" SET`)).WillReturnError(errors.New("database error"))
+	mock.ExpectRollback()
+	assert.Error(t, repo.Fail(context.Background(), 3, "boom", nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
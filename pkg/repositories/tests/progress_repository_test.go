@@ -0,0 +1,44 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressRepo_Save(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormProgressRepo(gormDB)
+	now := time.Now().UTC()
+
+	query := regexp.QuoteMeta(`INSERT INTO "reading_progresses" ("user_id","book_id","position","page","device_id","client_updated_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT ("user_id","book_id") DO UPDATE SET "position"="excluded"."position","page"="excluded"."page","device_id"="excluded"."device_id","client_updated_at"="excluded"."client_updated_at","updated_at"="excluded"."updated_at" WHERE "reading_progresses"."client_updated_at" < "excluded"."client_updated_at"`)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	applied, err := repo.Save(context.Background(), &models.ReadingProgress{UserID: 1, BookID: 2, Position: 500, DeviceID: "phone", ClientUpdatedAt: now})
+
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	// Более старая позиция с другого устройства не перезаписывает новую
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	applied, err = repo.Save(context.Background(), &models.ReadingProgress{UserID: 1, BookID: 2, Position: 100, DeviceID: "tablet", ClientUpdatedAt: now.Add(-time.Minute)})
+
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
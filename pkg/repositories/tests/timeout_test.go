@@ -0,0 +1,72 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var selectBookQuery = regexp.QuoteMeta(`SELECT * FROM "books" WHERE id = $1 AND "books"."deleted_at" IS NULL ORDER BY "books"."id" LIMIT $2`)
+
+func TestTimeoutPlugin_SlowQuery(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+	require.NoError(t, gormDB.Use(repositories.TimeoutPlugin{Timeout: 20 * time.Millisecond}))
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	// Запрос дольше дедлайна должен прерваться
+	mock.ExpectQuery(selectBookQuery).
+		WithArgs(1, 1).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	started := time.Now()
+	_, err := repo.GetByID(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+}
+
+func TestTimeoutPlugin_CancelledRequest(t *testing.T) {
+	gormDB, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	require.NoError(t, gormDB.Use(repositories.TimeoutPlugin{Timeout: time.Minute}))
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	// Клиент ушёл: запрос в базу даже не отправляется
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := repo.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTimeoutPlugin_TransactionCommits(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+	require.NoError(t, gormDB.Use(repositories.TimeoutPlugin{Timeout: time.Second}))
+
+	repo := repositories.NewGormBookRepo(gormDB)
+
+	// Дедлайн оборачивает только сами запросы, транзакция доживает до COMMIT
+	mock.ExpectBegin()
+	mock.ExpectQuery(insertBookQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(insertOutboxQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err := repo.Create(context.Background(), &models.Book{
+		Title:    "Timeout Book",
+		Content:  "This is a long enough content string to pass validation...",
+		AuthorID: 1,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
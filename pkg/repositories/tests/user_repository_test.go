@@ -0,0 +1,72 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+var setAdminQuery = regexp.QuoteMeta(`UPDATE "user_dbs" SET "is_admin"=$1,"version"=version + 1,"updated_at"=$2 WHERE id = $3 AND "user_dbs"."deleted_at" IS NULL`)
+
+func TestUserRepo_SetAdmin(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormUserRepo(gormDB)
+
+	// Права выдаются без проверки версии, но версия увеличивается
+	mock.ExpectBegin()
+	mock.ExpectExec(setAdminQuery).
+		WithArgs(true, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.SetAdmin(context.Background(), 7, true)
+	assert.NoError(t, err)
+
+	// Несуществующий пользователь
+	mock.ExpectBegin()
+	mock.ExpectExec(setAdminQuery).
+		WithArgs(false, sqlmock.AnyArg(), 999).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = repo.SetAdmin(context.Background(), 999, false)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Ошибка базы данных
+	mock.ExpectBegin()
+	mock.ExpectExec(setAdminQuery).
+		WithArgs(true, sqlmock.AnyArg(), 8).
+		WillReturnError(errors.New("database error"))
+	mock.ExpectRollback()
+
+	err = repo.SetAdmin(context.Background(), 8, true)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepo_SetPassword(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormUserRepo(gormDB)
+	hash := []byte("$2a$12$hash")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user_dbs" SET "password_hash"=$1,"version"=version + 1,"updated_at"=$2 WHERE id = $3`)).
+		WithArgs(hash, sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.SetPassword(context.Background(), 7, hash)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
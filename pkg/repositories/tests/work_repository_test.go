@@ -0,0 +1,61 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkRepo_GetByID_EditionStats(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormWorkRepo(gormDB)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "works" WHERE id = $1`)).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "original_id"}).AddRow(1, "Emma", 2))
+	// Издания загружаются вместе со статистикой и обложкой
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","title","language","work_id","author_id","version","updated_at","word_count","char_count","paragraph_count","reading_minutes","readability","cover_key" FROM "books" WHERE "books"."work_id" = $1`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "work_id", "word_count", "cover_key"}).AddRow(2, "Emma", 1, 160000, "covers/2.jpg"))
+
+	work, err := repo.GetByID(context.Background(), 1)
+
+	assert.NoError(t, err)
+	if assert.Len(t, work.Editions, 1) {
+		assert.Equal(t, 160000, work.Editions[0].WordCount)
+		assert.Equal(t, "covers/2.jpg", work.Editions[0].CoverKey)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkRepo_LinkTranslation_OriginalOfAnotherWork(t *testing.T) {
+	gormDB, mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repositories.NewGormWorkRepo(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","title","work_id" FROM "books" WHERE id = $1`)).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "work_id"}).AddRow(1, "Emma", 10))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "works" WHERE "works"."id" = $1`)).
+		WithArgs(10, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "original_id"}).AddRow(10, "Emma", 1))
+	// Книга 2 — оригинал другого произведения, переносить её нельзя
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "works" WHERE (original_id = $1 AND id <> $2)`)).
+		WithArgs(2, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	_, err := repo.LinkTranslation(context.Background(), 1, 2)
+
+	assert.ErrorIs(t, err, repositories.ErrOriginalOfWork)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
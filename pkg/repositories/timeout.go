@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const timeoutKey = "timeout:restore"
+
+// TimeoutPlugin bounds every statement with a deadline on top of whatever
+// the caller's context allows. The deadline is applied around the statement
+// itself, after GORM has opened its transaction: database/sql rolls a
+// transaction back when the context it was begun with is cancelled.
+//
+// Row is left alone because its rows are read after the callback returns.
+type TimeoutPlugin struct {
+	Timeout time.Duration
+}
+
+var _ gorm.Plugin = TimeoutPlugin{}
+
+func (TimeoutPlugin) Name() string {
+	return "timeout"
+}
+
+func (p TimeoutPlugin) Initialize(db *gorm.DB) error {
+	if p.Timeout <= 0 {
+		return nil
+	}
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, h := range hooks {
+		if err := h.before("timeout:before_"+h.operation, p.start); err != nil {
+			return err
+		}
+		if err := h.after("timeout:after_"+h.operation, finish); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type deadline struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+func (p TimeoutPlugin) start(db *gorm.DB) {
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, p.Timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(timeoutKey, deadline{parent: parent, cancel: cancel})
+}
+
+func finish(db *gorm.DB) {
+	v, ok := db.InstanceGet(timeoutKey)
+	if !ok {
+		return
+	}
+	d, ok := v.(deadline)
+	if !ok {
+		return
+	}
+	d.cancel()
+	db.Statement.Context = d.parent
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -10,15 +11,17 @@ import (
 )
 
 type UserRepo interface {
-    Create(user *models.UserDB) error
-    GetByID(id uint) (*models.UserDB, error)
-	IsExists(id uint) error
-	IsAuthor(id uint) (bool, error)
-    IsAuthors(ids []uint) (map[uint]bool, error)
-    GetAll(p *models.Pagination) (*models.Pagination, error)
-    Update(user *models.UpdateReq, id uint) error
-    Delete(id uint) error
-    GetByUsername(username string) (*models.UserDB, error)
+    Create(ctx context.Context, user *models.UserDB) error
+    GetByID(ctx context.Context, id uint) (*models.UserDB, error)
+	IsExists(ctx context.Context, id uint) error
+	IsAuthor(ctx context.Context, id uint) (bool, error)
+    IsAuthors(ctx context.Context, ids []uint) (map[uint]bool, error)
+    GetAll(ctx context.Context, p *models.Pagination) (*models.Pagination, error)
+    Update(ctx context.Context, user *models.UpdateReq, id uint, version uint) error
+    Delete(ctx context.Context, id uint) error
+    GetByUsername(ctx context.Context, username string) (*models.UserDB, error)
+    SetAdmin(ctx context.Context, id uint, admin bool) error
+    SetPassword(ctx context.Context, id uint, hash []byte) error
 }
 
 type GormUserRepo struct {
@@ -32,41 +35,46 @@ func NewGormUserRepo(db *gorm.DB) *GormUserRepo{
 	return &GormUserRepo{db: db}
 }
 
-func (r *GormUserRepo) Create(user *models.UserDB) error{
-	result := r.db.Create(user)
-	if result.RowsAffected == 0 {
-    return gorm.ErrRecordNotFound
-    }
-	return result.Error
+func (r *GormUserRepo) Create(ctx context.Context, user *models.UserDB) error{
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(user)
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := result.Error; err != nil {
+			return err
+		}
+		return enqueue(tx, models.TopicUserCreated, user.ID, models.EntityEvent{ID: user.ID, Version: user.Version})
+	})
 }
 
-func (r *GormUserRepo) GetByID(id uint) (*models.UserDB, error){
+func (r *GormUserRepo) GetByID(ctx context.Context, id uint) (*models.UserDB, error){
 	var user models.UserDB
-	result := r.db.Where("id = ?", id).First(&user)
-	if result.RowsAffected == 0 {
-    return nil, gorm.ErrRecordNotFound
-    }
+	result := r.db.WithContext(ctx).Where("id = ?", id).First(&user)
 	if err := result.Error; err != nil {
 		return nil, err
 	}
+	if result.RowsAffected == 0 {
+    return nil, gorm.ErrRecordNotFound
+    }
 	return &user, nil
 }
 
-func (r *GormUserRepo) IsExists(id uint) (error) {
+func (r *GormUserRepo) IsExists(ctx context.Context, id uint) (error) {
 	var user models.UserDB
-	result := r.db.Where("id = ?", id).First(&user)
-	if result.RowsAffected == 0 {
-    return gorm.ErrRecordNotFound
-    }
+	result := r.db.WithContext(ctx).Where("id = ?", id).First(&user)
 	if err := result.Error; err != nil {
 		return err
 	}
+	if result.RowsAffected == 0 {
+    return gorm.ErrRecordNotFound
+    }
 	return nil
 }
 
-func (r *GormUserRepo) IsAuthor(id uint) (bool, error) {
+func (r *GormUserRepo) IsAuthor(ctx context.Context, id uint) (bool, error) {
 	var author models.Author
-	result := r.db.Where("user_id = ?", id).First(&author)
+	result := r.db.WithContext(ctx).Where("user_id = ?", id).First(&author)
 	if result.RowsAffected == 0 {
     return false, gorm.ErrRecordNotFound
     }
@@ -77,14 +85,14 @@ func (r *GormUserRepo) IsAuthor(id uint) (bool, error) {
 }
 
 
-func (r *GormUserRepo) IsAuthors(ids []uint) (map[uint]bool, error) {
+func (r *GormUserRepo) IsAuthors(ctx context.Context, ids []uint) (map[uint]bool, error) {
     authorsSet := make(map[uint]bool)
     if len(ids) == 0 {
         return nil, errors.New("there are no user ids")
     }
 
     var authorUserIDs []uint
-    result := r.db.Model(&models.Author{}).Where("user_id IN ?", ids).Pluck("user_id", &authorUserIDs)
+    result := r.db.WithContext(ctx).Model(&models.Author{}).Where("user_id IN ?", ids).Pluck("user_id", &authorUserIDs)
     if err := result.Error; err != nil {
         return nil, err
     }
@@ -95,9 +103,9 @@ func (r *GormUserRepo) IsAuthors(ids []uint) (map[uint]bool, error) {
 }
 
 
-func (r *GormUserRepo) GetAll(p *models.Pagination) (*models.Pagination, error){
+func (r *GormUserRepo) GetAll(ctx context.Context, p *models.Pagination) (*models.Pagination, error){
 	var users []models.UserDB
-    result := r.db.Scopes(models.Paginate(users, p, r.db)).Find(&users)
+    result := r.db.WithContext(ctx).Scopes(models.Paginate(users, p, r.db.WithContext(ctx))).Find(&users)
 
 	p.Rows = users
     if len(p.Rows.([]models.UserDB)) == 0{
@@ -112,30 +120,37 @@ func (r *GormUserRepo) GetAll(p *models.Pagination) (*models.Pagination, error){
 	return p, nil
 }
 
-func (r *GormUserRepo) Update(user *models.UpdateReq, id uint) error{
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *GormUserRepo) Update(ctx context.Context, user *models.UpdateReq, id uint, version uint) error{
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
         var existing models.UserDB
         result := tx.Where("id = ?", id).First(&existing)
+        if err := result.Error; err != nil {
+            return err
+        }
         if result.RowsAffected == 0 {
             return gorm.ErrRecordNotFound
         }
-        if err := result.Error; err != nil {
-            return err
+        if existing.Version != version {
+            return ErrVersionMismatch
         }
         updates := models.UserDB{
             Username: user.Username,
+            Version:  version + 1,
         }
 
-        result = tx.Model(&existing).Updates(updates)
+        result = tx.Model(&existing).Where("version = ?", version).Updates(updates)
+        if err := result.Error; err != nil {
+            return err
+        }
         if result.RowsAffected == 0 {
-            return fmt.Errorf("no user found with id %d. Error: %v", id, result.Error)
+            return ErrVersionMismatch
         }
-        return result.Error
+        return enqueue(tx, models.TopicUserUpdated, id, models.EntityEvent{ID: id, Version: version + 1})
     })
 }
 
-func (r *GormUserRepo) Delete(id uint) error{
-    return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *GormUserRepo) Delete(ctx context.Context, id uint) error{
+    return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
         author := models.Author{UserID: id}
         if err := tx.Select("Books").Delete(&author).Error; err != nil && err != gorm.ErrRecordNotFound {
             return err
@@ -147,18 +162,45 @@ func (r *GormUserRepo) Delete(id uint) error{
         if result.RowsAffected == 0 {
             return fmt.Errorf("no user found with id %d. Error: %v", id, result.Error)
         }
-        return result.Error
+        if err := result.Error; err != nil {
+            return err
+        }
+        return enqueue(tx, models.TopicUserDeleted, id, models.EntityEvent{ID: id})
     })
 }
 
-func (r *GormUserRepo) GetByUsername(username string) (*models.UserDB, error) {
+func (r *GormUserRepo) GetByUsername(ctx context.Context, username string) (*models.UserDB, error) {
     var user models.UserDB
-	result := r.db.Where("username = ?", username).First(&user)
-	if result.RowsAffected == 0 {
-    return nil, gorm.ErrRecordNotFound
-    }
+	result := r.db.WithContext(ctx).Where("username = ?", username).First(&user)
 	if err := result.Error; err != nil {
 		return nil, err
 	}
+	if result.RowsAffected == 0 {
+    return nil, gorm.ErrRecordNotFound
+    }
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// SetAdmin and SetPassword are operator actions, so they skip the version
+// check that guards user edits; they still bump it to expire cached copies.
+func (r *GormUserRepo) SetAdmin(ctx context.Context, id uint, admin bool) error {
+    return r.setColumn(ctx, id, "is_admin", admin)
+}
+
+func (r *GormUserRepo) SetPassword(ctx context.Context, id uint, hash []byte) error {
+    return r.setColumn(ctx, id, "password_hash", hash)
+}
+
+func (r *GormUserRepo) setColumn(ctx context.Context, id uint, column string, value any) error {
+    result := r.db.WithContext(ctx).Model(&models.UserDB{}).Where("id = ?", id).Updates(map[string]interface{}{
+        column:    value,
+        "version": gorm.Expr("version + 1"),
+    })
+    if err := result.Error; err != nil {
+        return err
+    }
+    if result.RowsAffected == 0 {
+        return gorm.ErrRecordNotFound
+    }
+    return nil
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"gorm.io/gorm"
+)
+
+type WebhookRepo interface {
+	Create(ctx context.Context, hook *models.Webhook) error
+	GetByID(ctx context.Context, id uint, ownerID uint) (*models.Webhook, error)
+	ListByOwner(ctx context.Context, ownerID uint) ([]models.Webhook, error)
+	Delete(ctx context.Context, id uint, ownerID uint) error
+	ListSubscribed(ctx context.Context, event string, authorID uint) ([]models.Webhook, error)
+	LogDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID uint, p *models.Pagination) (*models.Pagination, error)
+}
+
+type GormWebhookRepo struct {
+	db *gorm.DB
+}
+
+var _ WebhookRepo = (*GormWebhookRepo)(nil)
+
+func NewGormWebhookRepo(db *gorm.DB) *GormWebhookRepo {
+	return &GormWebhookRepo{db: db}
+}
+
+func (r *GormWebhookRepo) Create(ctx context.Context, hook *models.Webhook) error {
+	return r.db.WithContext(ctx).Create(hook).Error
+}
+
+func (r *GormWebhookRepo) GetByID(ctx context.Context, id uint, ownerID uint) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := r.db.WithContext(ctx).Where("id = ? AND owner_id = ?", id, ownerID).First(&hook).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *GormWebhookRepo) ListByOwner(ctx context.Context, ownerID uint) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	if err := r.db.WithContext(ctx).Where("owner_id = ?", ownerID).Order("id").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *GormWebhookRepo) Delete(ctx context.Context, id uint, ownerID uint) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND owner_id = ?", id, ownerID).Delete(&models.Webhook{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListSubscribed returns active webhooks listening for event on books of
+// authorID, including global ones.
+func (r *GormWebhookRepo) ListSubscribed(ctx context.Context, event string, authorID uint) ([]models.Webhook, error) {
+	events, err := json.Marshal([]string{event})
+	if err != nil {
+		return nil, err
+	}
+	var hooks []models.Webhook
+	err = r.db.WithContext(ctx).
+		Where("active AND (global OR owner_id = ?) AND events @> ?", authorID, string(events)).
+		Find(&hooks).Error
+	if err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *GormWebhookRepo) LogDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+func (r *GormWebhookRepo) ListDeliveries(ctx context.Context, webhookID uint, p *models.Pagination) (*models.Pagination, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID).Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID).
+		Offset(int(p.GetOffset())).Limit(int(p.GetLimit())).Order(p.GetSort()).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	p.TotalRows = uint64(total)
+	p.TotalPages = uint((total + int64(p.GetLimit()) - 1) / int64(p.GetLimit()))
+	p.Rows = deliveries
+	return p, nil
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSameEdition     = errors.New("a book cannot be a translation of itself")
+	ErrOriginalEdition = errors.New("the original edition cannot be unlinked from its work")
+	ErrOriginalOfWork  = errors.New("the book is the original edition of another work")
+)
+
+type WorkRepo interface {
+	GetByID(ctx context.Context, id uint) (*models.Work, error)
+	LinkTranslation(ctx context.Context, originalID uint, translationID uint) (*models.Work, error)
+	UnlinkTranslation(ctx context.Context, bookID uint, translationID uint) error
+}
+
+type GormWorkRepo struct {
+	db *gorm.DB
+}
+
+var _ WorkRepo = (*GormWorkRepo)(nil)
+
+func NewGormWorkRepo(db *gorm.DB) *GormWorkRepo {
+	return &GormWorkRepo{db: db}
+}
+
+func (r *GormWorkRepo) GetByID(ctx context.Context, id uint) (*models.Work, error) {
+	var work models.Work
+	result := r.db.WithContext(ctx).Preload("Editions", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "title", "language", "work_id", "author_id", "version", "updated_at",
+			"word_count", "char_count", "paragraph_count", "reading_minutes", "readability", "cover_key").Order("id")
+	}).Where("id = ?", id).First(&work)
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return &work, nil
+}
+
+// LinkTranslation attaches translationID to the work of originalID, creating
+// the work on first use. A translation linked elsewhere is moved over, unless
+// it is the original of that work, which would leave the work without one.
+func (r *GormWorkRepo) LinkTranslation(ctx context.Context, originalID uint, translationID uint) (*models.Work, error) {
+	if originalID == translationID {
+		return nil, ErrSameEdition
+	}
+	var work models.Work
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var original models.Book
+		result := tx.Select("id", "title", "work_id").Where("id = ?", originalID).First(&original)
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := result.Error; err != nil {
+			return err
+		}
+
+		if original.WorkID == nil {
+			work = models.Work{Title: original.Title, OriginalID: original.ID}
+			if err := tx.Create(&work).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&original).Update("work_id", work.ID).Error; err != nil {
+				return err
+			}
+		} else if err := tx.First(&work, *original.WorkID).Error; err != nil {
+			return err
+		}
+		if work.OriginalID == translationID {
+			return ErrOriginalEdition
+		}
+		var originals int64
+		if err := tx.Model(&models.Work{}).Where("original_id = ? AND id <> ?", translationID, work.ID).Count(&originals).Error; err != nil {
+			return err
+		}
+		if originals > 0 {
+			return ErrOriginalOfWork
+		}
+
+		result = tx.Model(&models.Book{}).Where("id = ?", translationID).Update("work_id", work.ID)
+		if err := result.Error; err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &work, nil
+}
+
+// UnlinkTranslation detaches translationID from the work bookID belongs to.
+func (r *GormWorkRepo) UnlinkTranslation(ctx context.Context, bookID uint, translationID uint) error {
+	var book models.Book
+	result := r.db.WithContext(ctx).Select("id", "work_id").Where("id = ?", bookID).First(&book)
+	if result.RowsAffected == 0 || book.WorkID == nil {
+		return gorm.ErrRecordNotFound
+	}
+	if err := result.Error; err != nil {
+		return err
+	}
+	var work models.Work
+	if err := r.db.WithContext(ctx).Select("id", "original_id").First(&work, *book.WorkID).Error; err != nil {
+		return err
+	}
+	if work.OriginalID == translationID {
+		return ErrOriginalEdition
+	}
+	result = r.db.WithContext(ctx).Model(&models.Book{}).
+		Where("id = ? AND work_id = ?", translationID, *book.WorkID).
+		Update("work_id", nil)
+	if err := result.Error; err != nil {
+		return err
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -9,12 +9,12 @@ import (
 func RegisterAuthorRoutes(group *gin.RouterGroup, ctrl *controllers.AuthorController, AuthMiddleware gin.HandlerFunc) {
 	group.GET("/authors", ctrl.GetAll)
 	group.GET("/authors/:id", ctrl.GetByID)
-	group.GET("/authors/create", ctrl.GetCreateMock)
 	auth := group.Group("/")
 	auth.Use(AuthMiddleware)
 	{
 		auth.POST("/authors", ctrl.Create)
 		auth.PUT("/authors/me", ctrl.Update)
+		auth.POST("/authors/me/avatar", ctrl.UploadAvatar)
 		auth.DELETE("/authors/me", ctrl.Delete)
 	}
 }
\ No newline at end of file
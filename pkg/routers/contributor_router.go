@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterContributorRoutes(group *gin.RouterGroup, ctrl *controllers.ContributorController, AuthMiddleware gin.HandlerFunc, BooksMiddleware gin.HandlerFunc) {
+	group.GET("/books/:id/contributors", ctrl.GetAll)
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	auth.Use(BooksMiddleware)
+	{
+		auth.GET("/authors/me/invitations", ctrl.GetInvitations)
+		auth.POST("/books/:id/contributors", ctrl.Invite)
+		auth.POST("/books/:id/contributors/accept", ctrl.Accept)
+		auth.DELETE("/books/:id/contributors/:author_id", ctrl.Remove)
+	}
+}
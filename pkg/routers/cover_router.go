@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCoverRoutes(group *gin.RouterGroup, ctrl *controllers.CoverController, AuthMiddleware gin.HandlerFunc, BooksMiddleware gin.HandlerFunc) {
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	auth.Use(BooksMiddleware)
+	{
+		auth.POST("/books/:id/cover", ctrl.Upload)
+	}
+}
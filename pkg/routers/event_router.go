@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterEventRoutes(group *gin.RouterGroup, ctrl *controllers.EventController, AuthMiddleware gin.HandlerFunc, StreamMiddleware gin.HandlerFunc) {
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	{
+		auth.GET("/events/stream", StreamMiddleware, ctrl.Stream)
+	}
+}
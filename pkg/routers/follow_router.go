@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterFollowRoutes(group *gin.RouterGroup, ctrl *controllers.FollowController, AuthMiddleware gin.HandlerFunc) {
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	{
+		auth.POST("/authors/:id/follow", ctrl.Follow)
+		auth.DELETE("/authors/:id/follow", ctrl.Unfollow)
+		auth.GET("/users/me/feed", ctrl.GetFeed)
+	}
+}
@@ -0,0 +1,13 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHealthRoutes expects the root group: probes are not versioned.
+func RegisterHealthRoutes(group *gin.RouterGroup, ctrl *controllers.HealthController) {
+	group.GET("/healthz", ctrl.Live)
+	group.GET("/readyz", ctrl.Ready)
+}
@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterJobRoutes(group *gin.RouterGroup, ctrl *controllers.JobController, AuthMiddleware gin.HandlerFunc) {
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	{
+		auth.GET("/jobs/:id", ctrl.GetByID)
+	}
+}
@@ -0,0 +1,13 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMetricsRoutes expects the root group, where Prometheus scrapes by
+// default.
+func RegisterMetricsRoutes(group *gin.RouterGroup) {
+	group.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
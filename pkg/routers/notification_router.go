@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterNotificationRoutes(group *gin.RouterGroup, ctrl *controllers.NotificationController, AuthMiddleware gin.HandlerFunc) {
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	{
+		auth.GET("/users/me/notifications", ctrl.GetAll)
+		auth.POST("/users/me/notifications/read", ctrl.MarkAllRead)
+		auth.POST("/users/me/notifications/:id/read", ctrl.MarkRead)
+		auth.GET("/users/me/notification-preferences", ctrl.GetPreferences)
+		auth.PATCH("/users/me/notification-preferences", ctrl.UpdatePreferences)
+	}
+}
@@ -0,0 +1,11 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPageRoutes(group *gin.RouterGroup, ctrl *controllers.PageController) {
+	group.GET("/books/:id/pages", ctrl.GetPage)
+}
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterProgressRoutes(group *gin.RouterGroup, ctrl *controllers.ProgressController, AuthMiddleware gin.HandlerFunc, StreamMiddleware gin.HandlerFunc) {
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	{
+		auth.GET("/books/:id/progress", ctrl.Get)
+		auth.PUT("/books/:id/progress", ctrl.Update)
+		auth.GET("/books/:id/progress/sync", StreamMiddleware, ctrl.Sync)
+	}
+}
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTransferRoutes(group *gin.RouterGroup, ctrl *controllers.TransferController, AuthMiddleware gin.HandlerFunc, BooksMiddleware gin.HandlerFunc) {
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	{
+		auth.POST("/books/export", ctrl.Export)
+	}
+	authors := group.Group("/")
+	authors.Use(AuthMiddleware)
+	authors.Use(BooksMiddleware)
+	{
+		authors.POST("/books/import", ctrl.Import)
+	}
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWebhookRoutes(group *gin.RouterGroup, ctrl *controllers.WebhookController, AuthMiddleware gin.HandlerFunc, BooksMiddleware gin.HandlerFunc) {
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	auth.Use(BooksMiddleware)
+	{
+		auth.GET("/webhooks", ctrl.GetAll)
+		auth.POST("/webhooks", ctrl.Create)
+		auth.DELETE("/webhooks/:id", ctrl.Delete)
+		auth.GET("/webhooks/:id/deliveries", ctrl.GetDeliveries)
+		auth.POST("/webhooks/:id/test", ctrl.Test)
+	}
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"github.com/Quavke/eBookReader/pkg/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWorkRoutes(group *gin.RouterGroup, ctrl *controllers.WorkController, AuthMiddleware gin.HandlerFunc, BooksMiddleware gin.HandlerFunc) {
+	group.GET("/works/:id", ctrl.GetByID)
+	auth := group.Group("/")
+	auth.Use(AuthMiddleware)
+	auth.Use(BooksMiddleware)
+	{
+		auth.POST("/books/:id/translations", ctrl.LinkTranslation)
+		auth.DELETE("/books/:id/translations/:translation_id", ctrl.UnlinkTranslation)
+	}
+}
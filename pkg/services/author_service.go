@@ -4,41 +4,56 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/Quavke/eBookReader/pkg/models"
 	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/Quavke/eBookReader/pkg/storage"
 	"github.com/redis/go-redis/v9"
 )
 
 type AuthorService interface {
-	GetAllAuthors(limit, page uint, sort string)  (*models.Pagination, error)
-	GetAuthorByID(id uint) 									     (*models.AuthorResp, error)
-	CreateAuthor(author *models.Author)          error
-	UpdateAuthor(author *models.UpdateAuthorReq, id uint)  error
-	DeleteAuthor(id uint)                         error
+	GetAllAuthors(ctx context.Context, limit, page uint, sort string)  (*models.Pagination, error)
+	GetAuthorByID(ctx context.Context, id, booksLimit, booksPage uint)  (*models.AuthorResp, error)
+	CreateAuthor(ctx context.Context, author *models.Author)          error
+	UpdateAuthor(ctx context.Context, author *models.UpdateAuthorReq, id, version uint)  error
+	DeleteAuthor(ctx context.Context, id uint)                         error
+	UploadAvatar(ctx context.Context, id uint, data []byte)            (*models.ImageURLs, error)
+}
+
+// AvatarSizes are the thumbnail widths generated for every uploaded avatar.
+var AvatarSizes = map[string]int{
+	"small":  64,
+	"medium": 256,
 }
 
 type AuthorServiceImpl struct {
 	repo repositories.AuthorRepo
-	context context.Context
+	bookRepo repositories.BookRepo
+	followRepo repositories.FollowRepo
 	redisClient *redis.Client
+	store storage.BlobStore
 }
 
-func NewAuthorService(repo repositories.AuthorRepo, context context.Context, 	redisClient *redis.Client) *AuthorServiceImpl{
+func NewAuthorService(repo repositories.AuthorRepo, bookRepo repositories.BookRepo, followRepo repositories.FollowRepo, redisClient *redis.Client, store storage.BlobStore) *AuthorServiceImpl{
 	return &AuthorServiceImpl{
 		repo: repo,
-		context: context,
+		bookRepo: bookRepo,
+		followRepo: followRepo,
 		redisClient: redisClient,
+		store: store,
 	}
 }
 
 var _ AuthorService = (*AuthorServiceImpl)(nil)
 
-func (s *AuthorServiceImpl) GetAllAuthors(limit, page uint, sort string) (*models.Pagination, error){
+func (s *AuthorServiceImpl) GetAllAuthors(ctx context.Context, limit, page uint, sort string) (*models.Pagination, error){
+	ctx, span := tracing.Start(ctx, "AuthorService.GetAllAuthors")
+	defer span.End()
 	cacheKey := fmt.Sprintf("authors:limit=%d,page=%d,sort=%s", limit, page, sort)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var p models.Pagination
 		if err := json.Unmarshal([]byte(cachedData), &p); err == nil {
@@ -51,11 +66,19 @@ func (s *AuthorServiceImpl) GetAllAuthors(limit, page uint, sort string) (*model
 		Page: uint(page),
 		Sort: sort,
 	}
-	p, err = s.repo.GetAll(p)
+	p, err = s.repo.GetAll(ctx, p)
 	if err != nil {
 		return nil, err
 	}
 	rows := p.Rows.([]models.Author)
+	ids := make([]uint, 0, len(rows))
+	for _, a := range rows {
+		ids = append(ids, a.UserID)
+	}
+	followers, err := s.followRepo.CountFollowers(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
 	authors := make([]models.AuthorResp, 0, len(rows))
 	for _, a := range rows {
 		authors = append(authors, models.AuthorResp{
@@ -63,29 +86,57 @@ func (s *AuthorServiceImpl) GetAllAuthors(limit, page uint, sort string) (*model
 			Firstname: a.Firstname,
 			Lastname: a.Lastname,
 			Birthday: a.Birthday,
+			Avatar: imageURLs(s.store, a.AvatarKey, AvatarSizes),
+			PenNames: a.PenNames,
+			Website: a.Website,
+			Links: a.Links,
+			Country: a.Country,
+			Followers: followers[a.UserID],
+			Version: a.Version,
+			UpdatedAt: a.UpdatedAt,
 		})
+		p.Touch(a.UpdatedAt)
 	}
 	p.Rows = authors
 
 	data, err := json.Marshal(p)
   if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached authors data")
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached authors data")
   }
 
 	return p, nil
 }
 
-func (s *AuthorServiceImpl) GetAuthorByID(id uint) (*models.AuthorResp, error){
+func (s *AuthorServiceImpl) GetAuthorByID(ctx context.Context, id, booksLimit, booksPage uint) (*models.AuthorResp, error){
+	ctx, span := tracing.Start(ctx, "AuthorService.GetAuthorByID")
+	defer span.End()
+	author, err := s.getAuthorProfile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	books, err := s.getAuthorBooks(ctx, id, booksLimit, booksPage)
+	if err != nil {
+		return nil, err
+	}
+	author.Books = books
+	return author, nil
+}
+
+func (s *AuthorServiceImpl) getAuthorProfile(ctx context.Context, id uint) (*models.AuthorResp, error){
 	cacheKey := fmt.Sprintf("author:%d", id)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var author models.AuthorResp
 		if err := json.Unmarshal([]byte(cachedData), &author); err == nil {
 			return &author, nil
 		}
 	}
-	authorBD, err := s.repo.GetByID(id)
+	authorBD, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	followers, err := s.followRepo.CountFollowers(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -94,23 +145,69 @@ func (s *AuthorServiceImpl) GetAuthorByID(id uint) (*models.AuthorResp, error){
 		Firstname: authorBD.Firstname,
 		Lastname: authorBD.Lastname,
 		Birthday: authorBD.Birthday,
+		Bio: authorBD.Bio,
+		Avatar: imageURLs(s.store, authorBD.AvatarKey, AvatarSizes),
+		PenNames: authorBD.PenNames,
+		Website: authorBD.Website,
+		Links: authorBD.Links,
+		Country: authorBD.Country,
+		Followers: followers[id],
+		Version: authorBD.Version,
+		UpdatedAt: authorBD.UpdatedAt,
 	}
 
 	data, err := json.Marshal(author)
   if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached author data")
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached author data")
   }
 
 	return author, nil
 }
 
-func (s *AuthorServiceImpl) CreateAuthor(author *models.Author) error{
+func (s *AuthorServiceImpl) getAuthorBooks(ctx context.Context, id, limit, page uint) (*models.Pagination, error){
+	cacheKey := fmt.Sprintf("author_books:%d:limit=%d,page=%d", id, limit, page)
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
+	if err == nil && cachedData != "" {
+		var p models.Pagination
+		if err := json.Unmarshal([]byte(cachedData), &p); err == nil {
+			return &p, nil
+		}
+	}
+
+	p := &models.Pagination{
+		Limit: limit,
+		Page: page,
+		Sort: "id desc",
+	}
+	p, err = s.bookRepo.GetByAuthor(ctx, id, p)
+	if err != nil {
+		return nil, err
+	}
+	rows := p.Rows.([]models.Book)
+	books := make([]models.BookResp, 0, len(rows))
+	for _, b := range rows {
+		books = append(books, bookListItem(b, s.store))
+		p.Touch(b.UpdatedAt)
+	}
+	p.Rows = books
+
+	data, err := json.Marshal(p)
+  if err == nil {
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached author books data")
+  }
+	return p, nil
+}
+
+func (s *AuthorServiceImpl) CreateAuthor(ctx context.Context, author *models.Author) error{
+	ctx, span := tracing.Start(ctx, "AuthorService.CreateAuthor")
+	defer span.End()
 	if author.Firstname == "" && author.Lastname == "" && author.Birthday.IsZero() {
 		return nil
 	}
 	cacheKey := fmt.Sprintf("create_author:%d", author.UserID)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var result string
 		if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
@@ -121,7 +218,7 @@ func (s *AuthorServiceImpl) CreateAuthor(author *models.Author) error{
 			}
 		}
 	}
-	createResult := s.repo.Create(author)
+	createResult := s.repo.Create(ctx, author)
 	var result string
 	if createResult != nil {
 		result = createResult.Error()
@@ -130,44 +227,28 @@ func (s *AuthorServiceImpl) CreateAuthor(author *models.Author) error{
 	}
 	data, err := json.Marshal(result)
 	if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached create author data")
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached create author data")
   }
 	return createResult
 }
 
-func (s *AuthorServiceImpl) UpdateAuthor(author *models.UpdateAuthorReq, id uint) error{
-	cacheKey := fmt.Sprintf("update_author:firstname=%s,lastname=%s,birthday=%s", author.Firstname, author.Lastname, author.Birthday.Format("2006-01-02"))
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
-	if err == nil && cachedData != "" {
-		var result string
-		if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
-			if result == "success" {
-				return nil
-			} else {
-				return fmt.Errorf("cached error: %s", result)
-			}
-		}
+func (s *AuthorServiceImpl) UpdateAuthor(ctx context.Context, author *models.UpdateAuthorReq, id, version uint) error{
+	ctx, span := tracing.Start(ctx, "AuthorService.UpdateAuthor")
+	defer span.End()
+	// Not cached: the version check must run on every call.
+	if err := s.repo.Update(ctx, author, id, version); err != nil {
+		return err
 	}
-	
-	updateResult := s.repo.Update(author, id)
-	var result string
-	if updateResult != nil {
-		result = updateResult.Error()
-	} else {
-		result = "success"
-	}
-	data, err := json.Marshal(result)
-	if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached update author data")
-  }
-	return updateResult
+	s.redisClient.Del(ctx, fmt.Sprintf("author:%d", id))
+	return nil
 }
 
-func (s *AuthorServiceImpl) DeleteAuthor(id uint) error{
+func (s *AuthorServiceImpl) DeleteAuthor(ctx context.Context, id uint) error{
+	ctx, span := tracing.Start(ctx, "AuthorService.DeleteAuthor")
+	defer span.End()
 	cacheKey := fmt.Sprintf("delete_author:%d", id)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var result string
 		if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
@@ -179,7 +260,10 @@ func (s *AuthorServiceImpl) DeleteAuthor(id uint) error{
 		}
 	}
 
-	deleteResult := s.repo.Delete(id)
+	deleteResult := s.repo.Delete(ctx, id)
+	if deleteResult == nil {
+		s.redisClient.Del(ctx, fmt.Sprintf("author:%d", id))
+	}
 	var result string
 	if deleteResult != nil {
 		result = deleteResult.Error()
@@ -188,8 +272,27 @@ func (s *AuthorServiceImpl) DeleteAuthor(id uint) error{
 	}
 	data, err := json.Marshal(result)
 	if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached delete author data")
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached delete author data")
   }
 	return deleteResult
-}
\ No newline at end of file
+}
+
+func (s *AuthorServiceImpl) UploadAvatar(ctx context.Context, id uint, data []byte) (*models.ImageURLs, error){
+	ctx, span := tracing.Start(ctx, "AuthorService.UploadAvatar")
+	defer span.End()
+	originalKey, err := storeImage(ctx, s.store, fmt.Sprintf("avatars/%d", id), data, AvatarSizes)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.repo.UpdateAvatar(ctx, id, originalKey)
+	if err != nil {
+		deleteImage(ctx, s.store, originalKey, AvatarSizes)
+		return nil, err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("author:%d", id))
+	if previous != "" {
+		deleteImage(ctx, s.store, previous, AvatarSizes)
+	}
+	return imageURLs(s.store, originalKey, AvatarSizes), nil
+}
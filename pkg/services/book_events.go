@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/outbox"
+	"github.com/Quavke/eBookReader/pkg/storage"
+)
+
+// SubscribeBookEvents reacts to committed book changes: followers are
+// notified, open streams are told about updates and webhooks are called.
+// Each reaction is its own subscriber, and the relay retries a message only
+// for the subscribers that failed, so a failed notification does not call
+// the webhooks again. Pushes and webhooks are best effort and keyed so a
+// redelivery does not look like a new event.
+func SubscribeBookEvents(bus outbox.EventBus, notifications NotificationService, events EventService, webhooks WebhookService, store storage.BlobStore) {
+	bus.Subscribe(models.TopicBookCreated, "webhooks", func(ctx context.Context, e models.DomainEvent) error {
+		book, err := decodeBookEvent(e)
+		if err != nil {
+			return err
+		}
+		webhooks.Emit(ctx, webhookKey(e, "created"), models.WebhookBookCreated, book)
+		webhooks.Emit(ctx, webhookKey(e, "published"), models.WebhookBookPublished, book)
+		return nil
+	})
+	bus.Subscribe(models.TopicBookCreated, "notifications", func(ctx context.Context, e models.DomainEvent) error {
+		book, err := decodeBookEvent(e)
+		if err != nil {
+			return err
+		}
+		return notifications.NotifyNewBook(ctx, book)
+	})
+	bus.Subscribe(models.TopicBookUpdated, "webhooks", func(ctx context.Context, e models.DomainEvent) error {
+		book, err := decodeBookEvent(e)
+		if err != nil {
+			return err
+		}
+		webhooks.Emit(ctx, webhookKey(e, "updated"), models.WebhookBookUpdated, book)
+		return nil
+	})
+	bus.Subscribe(models.TopicBookUpdated, "followers", func(ctx context.Context, e models.DomainEvent) error {
+		book, err := decodeBookEvent(e)
+		if err != nil {
+			return err
+		}
+		if err := events.PublishToFollowers(ctx, book.AuthorID, models.EventBookUpdated, bookListItem(*book, store)); err != nil {
+			slog.ErrorContext(ctx, "Book events error, push event", "error", err)
+		}
+		return nil
+	})
+	bus.Subscribe(models.TopicBookDeleted, "webhooks", func(ctx context.Context, e models.DomainEvent) error {
+		book, err := decodeBookEvent(e)
+		if err != nil {
+			return err
+		}
+		webhooks.Emit(ctx, webhookKey(e, "deleted"), models.WebhookBookDeleted, book)
+		return nil
+	})
+}
+
+func decodeBookEvent(e models.DomainEvent) (*models.Book, error) {
+	var payload models.BookEvent
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("decode %s payload: %w", e.Topic, err)
+	}
+	return payload.Book(), nil
+}
+
+func webhookKey(e models.DomainEvent, action string) string {
+	return fmt.Sprintf("evt%d-%s", e.ID, action)
+}
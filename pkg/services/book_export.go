@@ -0,0 +1,42 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"html"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+var contentFormats = map[string]string{
+	"txt":  "text/plain; charset=utf-8",
+	"html": "text/html; charset=utf-8",
+}
+
+func renderBookHTML(title, content string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>")
+	buf.WriteString(html.EscapeString(title))
+	buf.WriteString("</title>\n</head>\n<body>\n<h1>")
+	buf.WriteString(html.EscapeString(title))
+	buf.WriteString("</h1>\n")
+	for _, paragraph := range splitParagraphs(content) {
+		buf.WriteString("<p>")
+		buf.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		buf.WriteString("</p>\n")
+	}
+	buf.WriteString("</body>\n</html>\n")
+	return buf.Bytes()
+}
+
+func splitParagraphs(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	var paragraphs []string
+	for _, p := range strings.Split(content, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return paragraphs
+}
@@ -5,41 +5,63 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/Quavke/eBookReader/pkg/models"
 	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/Quavke/eBookReader/pkg/storage"
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 )
 
 type BookService interface {
-	GetAllBooks(limit, page uint, sort string)       									  	(*models.Pagination, error)
-	GetBookByID(id uint) 									  						(*models.BookResp, error)
-	CreateBook(book *models.Book)           								 error
-	UpdateBook(book *models.Book, id, userID uint)   error
-	DeleteBook(id uint, userID uint)                      error
+	GetAllBooks(ctx context.Context, limit, page uint, sort string, filter *models.BookFilter)  (*models.Pagination, error)
+	GetBookByID(ctx context.Context, id uint) 									  						(*models.BookResp, error)
+	GetBookContent(ctx context.Context, id uint, format string)              (*models.BookContent, error)
+	CreateBook(ctx context.Context, book *models.Book)           								 error
+	ImportBook(ctx context.Context, book *models.Book, jobID string)           error
+	UpdateBook(ctx context.Context, book *models.Book, id, userID, version uint)   error
+	DeleteBook(ctx context.Context, id uint, userID uint)                      error
+	UnpublishBook(ctx context.Context, id uint)                      error
 }
 
 type BookServiceImpl struct {
 	repo repositories.BookRepo
-	context context.Context
+	contributorRepo repositories.ContributorRepo
 	redisClient *redis.Client
+	wordsPerMinute uint
+	store storage.BlobStore
 }
 
-func NewBookService(repo repositories.BookRepo, context context.Context, redisClient *redis.Client) *BookServiceImpl{
+func NewBookService(repo repositories.BookRepo, contributorRepo repositories.ContributorRepo, redisClient *redis.Client, wordsPerMinute uint, store storage.BlobStore) *BookServiceImpl{
 	return &BookServiceImpl{
 		repo: repo,
-		context: context,
+		contributorRepo: contributorRepo,
 		redisClient: redisClient,
+		wordsPerMinute: wordsPerMinute,
+		store: store,
 	}
 }
 
 var _ BookService = (*BookServiceImpl)(nil)
 
-func (s *BookServiceImpl) GetAllBooks(limit, page uint, sort string) (*models.Pagination, error){
-	cacheKey := fmt.Sprintf("books:limit=%d,page=%d,sort=%s", limit, page, sort)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+func (s *BookServiceImpl) GetAllBooks(ctx context.Context, limit, page uint, sort string, filter *models.BookFilter) (*models.Pagination, error){
+	ctx, span := tracing.Start(ctx, "BookService.GetAllBooks")
+	defer span.End()
+	order, err := bookSortOrder(sort)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil && filter.Language != "" {
+		if filter.Language, err = normalizeLanguage(filter.Language); err != nil {
+			return nil, err
+		}
+	}
+	cacheKey := fmt.Sprintf("books:limit=%d,page=%d,sort=%s,filter=%s", limit, page, order, bookFilterKey(filter))
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var p models.Pagination
 		if err := json.Unmarshal([]byte(cachedData), &p); err == nil {
@@ -49,43 +71,45 @@ func (s *BookServiceImpl) GetAllBooks(limit, page uint, sort string) (*models.Pa
 	p := &models.Pagination{
 		Limit: limit,
 		Page: page,
-		Sort: sort,
+		Sort: order,
 	}
-	p, err = s.repo.GetAll(p)
+	p, err = s.repo.GetAll(ctx, p, filter)
 	if err != nil {
 		return nil, err
 	}
 	rows := p.Rows.([]models.Book)
 	books := make([]models.BookResp, 0, len(rows))
 	for _, b := range rows {
-		books = append(books, models.BookResp{
-			ID: b.ID,
-			Title: b.Title,
-			Content: b.Content,
-			AuthorID: b.AuthorID,
-		})
+		books = append(books, bookListItem(b, s.store))
+		p.Touch(b.UpdatedAt)
 	}
 	p.Rows = books
 
 	data, err := json.Marshal(p)
   if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached books data")
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached books data")
   }
 
 	return p, nil
 }
 
-func (s *BookServiceImpl) GetBookByID(id uint) (*models.BookResp, error) {
+func (s *BookServiceImpl) GetBookByID(ctx context.Context, id uint) (*models.BookResp, error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetBookByID")
+	defer span.End()
 	cacheKey := fmt.Sprintf("book:%d", id)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var book models.BookResp
 		if err := json.Unmarshal([]byte(cachedData), &book); err == nil {
 			return &book, nil
 		}
 	}
-	bookDB, err := s.repo.GetByID(id)
+	bookDB, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	contributors, err := s.contributorRepo.ListByBook(ctx, id, true)
 	if err != nil {
 		return nil, err
 	}
@@ -94,21 +118,58 @@ func (s *BookServiceImpl) GetBookByID(id uint) (*models.BookResp, error) {
 		Title: bookDB.Title,
 		Content: bookDB.Content,
 		AuthorID: bookDB.AuthorID,
+		Contributors: contributorResps(bookDB.AuthorID, contributors),
+		Language: bookDB.Language,
+		WorkID: bookDB.WorkID,
+		Version: bookDB.Version,
+		Stats: bookDB.TextStats,
+		Cover: coverURLs(s.store, bookDB.CoverKey),
+		UpdatedAt: bookDB.UpdatedAt,
 	}
 	data, err := json.Marshal(book)
   if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached book data")
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached book data")
   }
 	return book, nil
 }
 
-func (s *BookServiceImpl) CreateBook(book *models.Book) error{
-	if len(book.Title) < 3 || len(book.Title) > 400 && len(book.Content) < 10 {
-		return errors.New("title must be between 3 and 400 characters and content must be at least 10 characters")
+func (s *BookServiceImpl) GetBookContent(ctx context.Context, id uint, format string) (*models.BookContent, error) {
+	ctx, span := tracing.Start(ctx, "BookService.GetBookContent")
+	defer span.End()
+	contentType, ok := contentFormats[format]
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+	bookDB, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	switch format {
+	case "html":
+		body = renderBookHTML(bookDB.Title, bookDB.Content)
+	default:
+		body = []byte(bookDB.Content)
+	}
+	return &models.BookContent{
+		BookID: bookDB.ID,
+		Format: format,
+		ContentType: contentType,
+		Body: body,
+		Version: bookDB.Version,
+		UpdatedAt: bookDB.UpdatedAt,
+	}, nil
+}
+
+func (s *BookServiceImpl) CreateBook(ctx context.Context, book *models.Book) error{
+	ctx, span := tracing.Start(ctx, "BookService.CreateBook")
+	defer span.End()
+	if err := s.prepareBook(book); err != nil {
+		return err
 	}
 	cacheKey := fmt.Sprintf("create_book:%d,title=%s", book.AuthorID, book.Title)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var result string
 		if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
@@ -119,7 +180,7 @@ func (s *BookServiceImpl) CreateBook(book *models.Book) error{
 			}
 		}
 	}
-	createResult := s.repo.Create(book)
+	createResult := s.repo.Create(ctx, book)
 	var result string
 	if createResult != nil {
 		result = createResult.Error()
@@ -128,50 +189,86 @@ func (s *BookServiceImpl) CreateBook(book *models.Book) error{
 	}
 	data, err := json.Marshal(result)
 	if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached create book data")
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached create book data")
   }
 	return createResult
 }
 
-func (s *BookServiceImpl) UpdateBook(book *models.Book, id, userID uint) error {
-	cacheKey := fmt.Sprintf("update_book:%d,title=%s", book.AuthorID, book.Title)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
-	if err == nil && cachedData != "" {
-		var result string
-		if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
-			if result == "success" {
-				return nil
-			} else {
-				return fmt.Errorf("cached error: %s", result)
-			}
-		}
+// ImportBook creates a book for the import job jobID. It skips CreateBook's
+// result cache, which would report success without setting book.ID, and it
+// is idempotent: when the job already created its book, book is filled in
+// from that one.
+func (s *BookServiceImpl) ImportBook(ctx context.Context, book *models.Book, jobID string) error {
+	ctx, span := tracing.Start(ctx, "BookService.ImportBook")
+	defer span.End()
+	if jobID == "" {
+		return errors.New("import job id is required")
+	}
+	existing, err := s.repo.GetImported(ctx, jobID)
+	if err == nil {
+		*book = *existing
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err := s.prepareBook(book); err != nil {
+		return err
+	}
+	return s.repo.CreateImported(ctx, book, jobID)
+}
+
+// prepareBook validates a new book, normalizes its language and computes
+// its stats.
+func (s *BookServiceImpl) prepareBook(book *models.Book) error {
+	if len(book.Title) < 3 || len(book.Title) > 400 && len(book.Content) < 10 {
+		return errors.New("title must be between 3 and 400 characters and content must be at least 10 characters")
 	}
+	lang, err := normalizeLanguage(book.Language)
+	if err != nil {
+		return err
+	}
+	book.Language = lang
+	book.TextStats = ComputeTextStats(book.Content, s.wordsPerMinute)
+	return nil
+}
 
-	isBelongs, err := s.repo.IsBelongsTo(id, userID)
+func (s *BookServiceImpl) UpdateBook(ctx context.Context, book *models.Book, id, userID, version uint) error {
+	ctx, span := tracing.Start(ctx, "BookService.UpdateBook")
+	defer span.End()
+	// No result cache here: a replayed "success" would hide a stale version,
+	// so the repository's version check runs on every call.
+	isBelongs, err := s.repo.IsBelongsTo(ctx, id, userID)
 	if err != nil && !isBelongs{
 		return err
 	}
 
 
-	updateResult :=  s.repo.Update(book, id)
-	var result string
-	if updateResult != nil {
-		result = updateResult.Error()
-	} else {
-		result = "success"
+	if book.Language != "" {
+		if book.Language, err = normalizeLanguage(book.Language); err != nil {
+			return err
+		}
 	}
-	data, err := json.Marshal(result)
-	if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached update author data")
-  }
-	return updateResult
+	if book.Content != "" {
+		book.TextStats = ComputeTextStats(book.Content, s.wordsPerMinute)
+	}
+	workID, err := s.repo.GetWorkID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, book, id, version); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, bookCacheKeys(id, workID)...)
+	return nil
 }
 
-func (s *BookServiceImpl) DeleteBook(id uint, userID uint) error {
+func (s *BookServiceImpl) DeleteBook(ctx context.Context, id uint, userID uint) error {
+	ctx, span := tracing.Start(ctx, "BookService.DeleteBook")
+	defer span.End()
 	cacheKey := fmt.Sprintf("delete_book:%d", id)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var result string
 		if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
@@ -183,12 +280,20 @@ func (s *BookServiceImpl) DeleteBook(id uint, userID uint) error {
 		}
 	}
 
-	isBelongs, err := s.repo.IsBelongsTo(id, userID)
-	if err != nil && !isBelongs{
+	// Co-authors may edit the book, but only the primary author may delete it.
+	isPrimary, err := s.repo.IsPrimaryAuthor(ctx, id, userID)
+	if err != nil && !isPrimary{
 		return err
 	}
 
-	deleteResult := s.repo.Delete(id)
+	workID, err := s.repo.GetWorkID(ctx, id)
+	if err != nil {
+		return err
+	}
+	deleteResult := s.repo.Delete(ctx, id)
+	if deleteResult == nil {
+		s.redisClient.Del(ctx, bookCacheKeys(id, workID)...)
+	}
 	var result string
 	if deleteResult != nil {
 		result = deleteResult.Error()
@@ -197,8 +302,98 @@ func (s *BookServiceImpl) DeleteBook(id uint, userID uint) error {
 	}
 	data, err := json.Marshal(result)
 	if err == nil {
-      s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-			log.Print("Cached delete author data")
+      s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+			slog.DebugContext(ctx, "Cached delete author data")
   }
 	return deleteResult
-}
\ No newline at end of file
+}
+
+// UnpublishBook removes a book on behalf of an operator, without the
+// primary-author check of DeleteBook.
+func (s *BookServiceImpl) UnpublishBook(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "BookService.UnpublishBook")
+	defer span.End()
+	workID, err := s.repo.GetWorkID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, bookCacheKeys(id, workID)...)
+	return nil
+}
+
+// bookCacheKeys lists the cache entries that embed the book: its own and,
+// for an edition, the work's list of editions.
+func bookCacheKeys(id uint, workID *uint) []string {
+	keys := []string{fmt.Sprintf("book:%d", id)}
+	if workID != nil {
+		keys = append(keys, fmt.Sprintf("work:%d", *workID))
+	}
+	return keys
+}
+
+// bookListItem is the BookResp used in collections: it never carries Content.
+func bookListItem(b models.Book, store storage.BlobStore) models.BookResp {
+	return models.BookResp{
+		ID: b.ID,
+		Title: b.Title,
+		AuthorID: b.AuthorID,
+		Language: b.Language,
+		WorkID: b.WorkID,
+		Version: b.Version,
+		Stats: b.TextStats,
+		Cover: coverURLs(store, b.CoverKey),
+		UpdatedAt: b.UpdatedAt,
+	}
+}
+
+var ErrInvalidSort = errors.New("unsupported sort key")
+
+var bookSortColumns = map[string]string{
+	"id":           "id",
+	"title":        "title",
+	"created":      "created_at",
+	"updated":      "updated_at",
+	"words":        "word_count",
+	"reading_time": "reading_minutes",
+	"readability":  "readability",
+}
+
+// bookSortOrder turns a public sort key such as "-words" into an ORDER BY clause.
+func bookSortOrder(sort string) (string, error) {
+	if sort == "" {
+		return "id desc", nil
+	}
+	direction := "asc"
+	if strings.HasPrefix(sort, "-") {
+		direction = "desc"
+		sort = sort[1:]
+	}
+	column, ok := bookSortColumns[sort]
+	if !ok {
+		return "", ErrInvalidSort
+	}
+	if column == "id" {
+		return "id " + direction, nil
+	}
+	return column + " " + direction + ", id desc", nil
+}
+
+func bookFilterKey(filter *models.BookFilter) string {
+	if filter == nil {
+		return "none"
+	}
+	key := fmt.Sprintf("words=%d-%d,minutes=%d-%d", filter.MinWords, filter.MaxWords, filter.MinMinutes, filter.MaxMinutes)
+	if filter.MinReadability != nil {
+		key += fmt.Sprintf(",min_readability=%g", *filter.MinReadability)
+	}
+	if filter.MaxReadability != nil {
+		key += fmt.Sprintf(",max_readability=%g", *filter.MaxReadability)
+	}
+	if filter.Language != "" {
+		key += ",language=" + filter.Language
+	}
+	return key
+}
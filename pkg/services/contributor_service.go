@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrNotPrimaryAuthor   = errors.New("only the primary author can manage contributors")
+	ErrInvalidContributor = errors.New("the primary author cannot be invited as a contributor")
+)
+
+type ContributorService interface {
+	ListContributors(ctx context.Context, bookID uint) ([]models.ContributorResp, error)
+	InviteContributor(ctx context.Context, bookID, userID uint, req *models.InviteContributorReq) error
+	AcceptInvitation(ctx context.Context, bookID, userID uint) error
+	RemoveContributor(ctx context.Context, bookID, userID, authorID uint) error
+	ListInvitations(ctx context.Context, userID uint) ([]models.BookContributor, error)
+}
+
+type ContributorServiceImpl struct {
+	repo repositories.ContributorRepo
+	bookRepo repositories.BookRepo
+	authorRepo repositories.AuthorRepo
+	redisClient *redis.Client
+}
+
+func NewContributorService(repo repositories.ContributorRepo, bookRepo repositories.BookRepo, authorRepo repositories.AuthorRepo, redisClient *redis.Client) *ContributorServiceImpl {
+	return &ContributorServiceImpl{
+		repo: repo,
+		bookRepo: bookRepo,
+		authorRepo: authorRepo,
+		redisClient: redisClient,
+	}
+}
+
+var _ ContributorService = (*ContributorServiceImpl)(nil)
+
+func (s *ContributorServiceImpl) ListContributors(ctx context.Context, bookID uint) ([]models.ContributorResp, error) {
+	ctx, span := tracing.Start(ctx, "ContributorService.ListContributors")
+	defer span.End()
+	book, err := s.bookRepo.GetByID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	contributors, err := s.repo.ListByBook(ctx, bookID, true)
+	if err != nil {
+		return nil, err
+	}
+	return contributorResps(book.AuthorID, contributors), nil
+}
+
+func (s *ContributorServiceImpl) InviteContributor(ctx context.Context, bookID, userID uint, req *models.InviteContributorReq) error {
+	ctx, span := tracing.Start(ctx, "ContributorService.InviteContributor")
+	defer span.End()
+	isPrimary, err := s.bookRepo.IsPrimaryAuthor(ctx, bookID, userID)
+	if err != nil || !isPrimary {
+		return ErrNotPrimaryAuthor
+	}
+	if req.AuthorID == userID {
+		return ErrInvalidContributor
+	}
+	if _, err := s.authorRepo.GetByID(ctx, req.AuthorID); err != nil {
+		return err
+	}
+	err = s.repo.Invite(ctx, &models.BookContributor{
+		BookID: bookID,
+		AuthorID: req.AuthorID,
+		Role: req.Role,
+		Position: req.Position,
+		InvitedBy: userID,
+	})
+	if err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("book:%d", bookID))
+	return nil
+}
+
+func (s *ContributorServiceImpl) AcceptInvitation(ctx context.Context, bookID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "ContributorService.AcceptInvitation")
+	defer span.End()
+	if err := s.repo.Accept(ctx, bookID, userID); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("book:%d", bookID))
+	return nil
+}
+
+// RemoveContributor lets the primary author remove anyone, and a contributor
+// remove themselves, which also declines a pending invitation.
+func (s *ContributorServiceImpl) RemoveContributor(ctx context.Context, bookID, userID, authorID uint) error {
+	ctx, span := tracing.Start(ctx, "ContributorService.RemoveContributor")
+	defer span.End()
+	if userID != authorID {
+		isPrimary, err := s.bookRepo.IsPrimaryAuthor(ctx, bookID, userID)
+		if err != nil || !isPrimary {
+			return ErrNotPrimaryAuthor
+		}
+	}
+	if err := s.repo.Remove(ctx, bookID, authorID); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("book:%d", bookID))
+	return nil
+}
+
+func (s *ContributorServiceImpl) ListInvitations(ctx context.Context, userID uint) ([]models.BookContributor, error) {
+	ctx, span := tracing.Start(ctx, "ContributorService.ListInvitations")
+	defer span.End()
+	return s.repo.ListInvitations(ctx, userID)
+}
+
+// contributorResps lists the primary author first, followed by the accepted
+// contributors in their display order.
+func contributorResps(primaryID uint, contributors []models.BookContributor) []models.ContributorResp {
+	resp := make([]models.ContributorResp, 0, len(contributors)+1)
+	resp = append(resp, models.ContributorResp{AuthorID: primaryID, Role: models.RoleAuthor, Primary: true})
+	for _, c := range contributors {
+		if c.AuthorID == primaryID {
+			continue
+		}
+		resp = append(resp, models.ContributorResp{AuthorID: c.AuthorID, Role: c.Role, Position: c.Position})
+	}
+	return resp
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/Quavke/eBookReader/pkg/storage"
+	"github.com/Quavke/eBookReader/pkg/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrNotBookAuthor = errors.New("book does not belong to this author")
+	ErrCoverTooLarge = errors.New("cover file is too large")
+)
+
+// CoverSizes are the thumbnail widths generated for every uploaded cover.
+var CoverSizes = map[string]int{
+	"small":  160,
+	"medium": 320,
+	"large":  640,
+}
+
+type CoverService interface {
+	UploadCover(ctx context.Context, bookID, userID uint, data []byte) (*models.ImageURLs, error)
+}
+
+type CoverServiceImpl struct {
+	repo repositories.BookRepo
+	store storage.BlobStore
+	redisClient *redis.Client
+	maxSize int64
+}
+
+func NewCoverService(repo repositories.BookRepo, store storage.BlobStore, redisClient *redis.Client, maxSize int64) *CoverServiceImpl {
+	return &CoverServiceImpl{
+		repo: repo,
+		store: store,
+		redisClient: redisClient,
+		maxSize: maxSize,
+	}
+}
+
+var _ CoverService = (*CoverServiceImpl)(nil)
+
+func (s *CoverServiceImpl) UploadCover(ctx context.Context, bookID, userID uint, data []byte) (*models.ImageURLs, error) {
+	ctx, span := tracing.Start(ctx, "CoverService.UploadCover")
+	defer span.End()
+	if s.maxSize > 0 && int64(len(data)) > s.maxSize {
+		return nil, ErrCoverTooLarge
+	}
+	if _, _, err := utils.DetectImageType(data); err != nil {
+		return nil, err
+	}
+	isBelongs, err := s.repo.IsBelongsTo(ctx, bookID, userID)
+	if err != nil || !isBelongs {
+		return nil, ErrNotBookAuthor
+	}
+
+	workID, err := s.repo.GetWorkID(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	originalKey, err := storeImage(ctx, s.store, fmt.Sprintf("covers/%d", bookID), data, CoverSizes)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.repo.UpdateCover(ctx, bookID, originalKey)
+	if err != nil {
+		deleteImage(ctx, s.store, originalKey, CoverSizes)
+		return nil, err
+	}
+	s.redisClient.Del(ctx, bookCacheKeys(bookID, workID)...)
+	if previous != "" {
+		deleteImage(ctx, s.store, previous, CoverSizes)
+	}
+	return imageURLs(s.store, originalKey, CoverSizes), nil
+}
+
+func coverURLs(store storage.BlobStore, originalKey string) *models.ImageURLs {
+	return imageURLs(store, originalKey, CoverSizes)
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrInvalidEPUB = errors.New("file is not a valid EPUB")
+
+// maxEPUBEntry bounds a single decompressed file, so a zip bomb cannot
+// exhaust memory.
+const maxEPUBEntry = 32 << 20
+
+type EPUBBook struct {
+	Title    string
+	Language string
+	Content  string
+}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Title    string `xml:"metadata>title"`
+	Language string `xml:"metadata>language"`
+	Manifest []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// ParseEPUB reads the title and language from the package document and the
+// text of every spine document in reading order. Markup is dropped;
+// block elements become paragraph breaks.
+func ParseEPUB(data []byte) (*EPUBBook, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEPUB, err.Error())
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var container epubContainer
+	if err := decodeEPUBEntry(files, "META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, fmt.Errorf("%w: no rootfile in container.xml", ErrInvalidEPUB)
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := decodeEPUBEntry(files, opfPath, &pkg); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(pkg.Title) == "" {
+		return nil, fmt.Errorf("%w: no title in package document", ErrInvalidEPUB)
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+	var paragraphs []string
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		doc, err := readEPUBEntry(files, path.Join(path.Dir(opfPath), href))
+		if err != nil {
+			return nil, err
+		}
+		paragraphs = append(paragraphs, xhtmlParagraphs(doc)...)
+	}
+	if len(paragraphs) == 0 {
+		return nil, fmt.Errorf("%w: no text in spine", ErrInvalidEPUB)
+	}
+	return &EPUBBook{
+		Title:    strings.TrimSpace(pkg.Title),
+		Language: strings.TrimSpace(pkg.Language),
+		Content:  strings.Join(paragraphs, "\n\n"),
+	}, nil
+}
+
+func decodeEPUBEntry(files map[string]*zip.File, name string, v any) error {
+	data, err := readEPUBEntry(files, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidEPUB, name, err.Error())
+	}
+	return nil
+}
+
+func readEPUBEntry(files map[string]*zip.File, name string) ([]byte, error) {
+	f, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidEPUB, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidEPUB, name, err.Error())
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxEPUBEntry+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidEPUB, name, err.Error())
+	}
+	if len(data) > maxEPUBEntry {
+		return nil, fmt.Errorf("%w: %s is too large", ErrInvalidEPUB, name)
+	}
+	return data, nil
+}
+
+var epubBlocks = map[string]bool{
+	"p": true, "div": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"li": true, "blockquote": true, "section": true, "tr": true, "pre": true,
+}
+
+// xhtmlParagraphs is lenient: EPUBs in the wild often carry HTML entities
+// and unclosed tags that strict XML parsing rejects.
+func xhtmlParagraphs(doc []byte) []string {
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var paragraphs []string
+	var current strings.Builder
+	flush := func() {
+		if p := strings.Join(strings.Fields(current.String()), " "); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+		current.Reset()
+	}
+	skip := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch name := strings.ToLower(t.Name.Local); {
+			case name == "head" || name == "script" || name == "style":
+				skip++
+			case name == "br":
+				current.WriteString(" ")
+			case epubBlocks[name]:
+				flush()
+			}
+		case xml.EndElement:
+			switch name := strings.ToLower(t.Name.Local); {
+			case name == "head" || name == "script" || name == "style":
+				skip--
+			case epubBlocks[name]:
+				flush()
+			}
+		case xml.CharData:
+			if skip == 0 {
+				current.Write(t)
+			}
+		}
+	}
+	flush()
+	return paragraphs
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Quavke/eBookReader/pkg/events"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+)
+
+type EventService interface {
+	Subscribe(ctx context.Context, userID uint, lastEventID string) (<-chan models.Event, error)
+	PublishToUser(ctx context.Context, eventType string, data any, userIDs ...uint) error
+	PublishToFollowers(ctx context.Context, authorID uint, eventType string, data any) error
+}
+
+type EventServiceImpl struct {
+	broker events.Broker
+	followRepo repositories.FollowRepo
+}
+
+func NewEventService(broker events.Broker, followRepo repositories.FollowRepo) *EventServiceImpl {
+	return &EventServiceImpl{
+		broker: broker,
+		followRepo: followRepo,
+	}
+}
+
+var _ EventService = (*EventServiceImpl)(nil)
+
+func (s *EventServiceImpl) Subscribe(ctx context.Context, userID uint, lastEventID string) (<-chan models.Event, error) {
+	ctx, span := tracing.Start(ctx, "EventService.Subscribe")
+	defer span.End()
+	return s.broker.Subscribe(ctx, userID, lastEventID)
+}
+
+func (s *EventServiceImpl) PublishToUser(ctx context.Context, eventType string, data any, userIDs ...uint) error {
+	ctx, span := tracing.Start(ctx, "EventService.PublishToUser")
+	defer span.End()
+	for _, id := range userIDs {
+		if err := s.broker.Publish(ctx, id, eventType, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishToFollowers also delivers to the author, whose other devices want to
+// see their own changes.
+func (s *EventServiceImpl) PublishToFollowers(ctx context.Context, authorID uint, eventType string, data any) error {
+	ctx, span := tracing.Start(ctx, "EventService.PublishToFollowers")
+	defer span.End()
+	followers, err := s.followRepo.Followers(ctx, authorID)
+	if err != nil {
+		return err
+	}
+	return s.PublishToUser(ctx, eventType, data, append(followers, authorID)...)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/Quavke/eBookReader/pkg/storage"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrFollowSelf    = errors.New("you cannot follow yourself")
+	ErrInvalidCursor = errors.New("invalid feed cursor")
+)
+
+const maxFeedLimit = 100
+
+type FollowService interface {
+	Follow(ctx context.Context, userID, authorID uint) error
+	Unfollow(ctx context.Context, userID, authorID uint) error
+	GetFeed(ctx context.Context, userID uint, cursor string, limit uint) (*models.FeedPage, error)
+}
+
+type FollowServiceImpl struct {
+	repo repositories.FollowRepo
+	authorRepo repositories.AuthorRepo
+	redisClient *redis.Client
+	store storage.BlobStore
+}
+
+func NewFollowService(repo repositories.FollowRepo, authorRepo repositories.AuthorRepo, redisClient *redis.Client, store storage.BlobStore) *FollowServiceImpl {
+	return &FollowServiceImpl{
+		repo: repo,
+		authorRepo: authorRepo,
+		redisClient: redisClient,
+		store: store,
+	}
+}
+
+var _ FollowService = (*FollowServiceImpl)(nil)
+
+func (s *FollowServiceImpl) Follow(ctx context.Context, userID, authorID uint) error {
+	ctx, span := tracing.Start(ctx, "FollowService.Follow")
+	defer span.End()
+	if userID == authorID {
+		return ErrFollowSelf
+	}
+	if _, err := s.authorRepo.GetByID(ctx, authorID); err != nil {
+		return err
+	}
+	if err := s.repo.Follow(ctx, userID, authorID); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("author:%d", authorID))
+	return nil
+}
+
+func (s *FollowServiceImpl) Unfollow(ctx context.Context, userID, authorID uint) error {
+	ctx, span := tracing.Start(ctx, "FollowService.Unfollow")
+	defer span.End()
+	if err := s.repo.Unfollow(ctx, userID, authorID); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("author:%d", authorID))
+	return nil
+}
+
+// GetFeed is not cached: it is personal and cheap thanks to keyset pagination.
+func (s *FollowServiceImpl) GetFeed(ctx context.Context, userID uint, cursor string, limit uint) (*models.FeedPage, error) {
+	ctx, span := tracing.Start(ctx, "FollowService.GetFeed")
+	defer span.End()
+	if limit == 0 || limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+	after, err := DecodeFeedCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	// Fetch one extra row to learn whether another page exists.
+	books, err := s.repo.Feed(ctx, userID, after, int(limit)+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.FeedPage{Items: make([]models.BookResp, 0, len(books))}
+	if len(books) > int(limit) {
+		books = books[:limit]
+		last := books[len(books)-1]
+		page.NextCursor = EncodeFeedCursor(models.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	for _, b := range books {
+		page.Items = append(page.Items, bookListItem(b, s.store))
+	}
+	return page, nil
+}
+
+func EncodeFeedCursor(c models.FeedCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatUint(uint64(c.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeFeedCursor returns nil for an empty cursor, meaning the first page.
+func DecodeFeedCursor(cursor string) (*models.FeedCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	i, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &models.FeedCursor{CreatedAt: time.Unix(0, n).UTC(), ID: uint(i)}, nil
+}
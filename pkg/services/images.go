@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/storage"
+	"github.com/Quavke/eBookReader/pkg/utils"
+)
+
+// storeImage validates data by its magic bytes and stores the original plus a
+// JPEG thumbnail per entry of sizes under a fresh prefix, so public URLs can be
+// cached forever. It returns the key of the original.
+func storeImage(ctx context.Context, store storage.BlobStore, prefix string, data []byte, sizes map[string]int) (string, error) {
+	mime, ext, err := utils.DetectImageType(data)
+	if err != nil {
+		return "", err
+	}
+	img, err := utils.DecodeImage(data)
+	if err != nil {
+		return "", err
+	}
+
+	dir := fmt.Sprintf("%s/%s", prefix, strconv.FormatInt(time.Now().UnixNano(), 36))
+	originalKey := dir + "/original." + ext
+	if err := store.Put(ctx, originalKey, bytes.NewReader(data), int64(len(data)), mime); err != nil {
+		return "", err
+	}
+	for name, width := range sizes {
+		thumb, err := utils.ThumbnailJPEG(img, width)
+		if err != nil {
+			return "", err
+		}
+		if err := store.Put(ctx, dir+"/"+name+".jpg", bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+			return "", err
+		}
+	}
+	return originalKey, nil
+}
+
+func deleteImage(ctx context.Context, store storage.BlobStore, originalKey string, sizes map[string]int) {
+	keys := []string{originalKey}
+	for name := range sizes {
+		keys = append(keys, path.Dir(originalKey)+"/"+name+".jpg")
+	}
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "Image storage error, delete blob", "key", key, "error", err)
+		}
+	}
+}
+
+func imageURLs(store storage.BlobStore, originalKey string, sizes map[string]int) *models.ImageURLs {
+	if originalKey == "" || store == nil {
+		return nil
+	}
+	urls := &models.ImageURLs{
+		Original:   store.URL(originalKey),
+		Thumbnails: make(map[string]string, len(sizes)),
+	}
+	for name := range sizes {
+		urls.Thumbnails[name] = store.URL(path.Dir(originalKey) + "/" + name + ".jpg")
+	}
+	return urls
+}
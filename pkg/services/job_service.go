@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+
+	"github.com/Quavke/eBookReader/pkg/jobs"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+)
+
+type JobService interface {
+	GetJob(ctx context.Context, id string, ownerID uint) (*models.JobResp, error)
+	Enqueue(ctx context.Context, jobType string, ownerID uint, payload any) (*models.JobResp, error)
+}
+
+type JobServiceImpl struct {
+	queue jobs.Queue
+}
+
+func NewJobService(queue jobs.Queue) *JobServiceImpl {
+	return &JobServiceImpl{
+		queue: queue,
+	}
+}
+
+var _ JobService = (*JobServiceImpl)(nil)
+
+// GetJob hides other users' jobs behind jobs.ErrJobNotFound, so ids cannot be
+// probed.
+func (s *JobServiceImpl) GetJob(ctx context.Context, id string, ownerID uint) (*models.JobResp, error) {
+	ctx, span := tracing.Start(ctx, "JobService.GetJob")
+	defer span.End()
+	job, err := s.queue.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.OwnerID != ownerID {
+		return nil, jobs.ErrJobNotFound
+	}
+	return jobResp(job), nil
+}
+
+func (s *JobServiceImpl) Enqueue(ctx context.Context, jobType string, ownerID uint, payload any) (*models.JobResp, error) {
+	ctx, span := tracing.Start(ctx, "JobService.Enqueue")
+	defer span.End()
+	job, err := jobs.New(jobType, ownerID, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return jobResp(job), nil
+}
+
+func jobResp(j *models.Job) *models.JobResp {
+	return &models.JobResp{
+		ID: j.ID,
+		Type: j.Type,
+		Status: j.Status,
+		Attempts: j.Attempts,
+		Result: j.Result,
+		Error: j.Error,
+		CreatedAt: j.CreatedAt,
+		UpdatedAt: j.UpdatedAt,
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrUnknownNotificationType = errors.New("unknown notification type")
+
+type NotificationService interface {
+	Notify(ctx context.Context, notificationType string, payload models.NotificationPayload, userIDs ...uint) error
+	NotifyNewBook(ctx context.Context, book *models.Book) error
+	GetInbox(ctx context.Context, userID, limit, page uint, unreadOnly bool) (*models.NotificationInbox, error)
+	MarkRead(ctx context.Context, userID, id uint) error
+	MarkAllRead(ctx context.Context, userID uint) error
+	GetPreferences(ctx context.Context, userID uint) (map[string]bool, error)
+	UpdatePreferences(ctx context.Context, userID uint, prefs map[string]bool) (map[string]bool, error)
+}
+
+type NotificationServiceImpl struct {
+	repo repositories.NotificationRepo
+	followRepo repositories.FollowRepo
+	events EventService
+	redisClient *redis.Client
+}
+
+func NewNotificationService(repo repositories.NotificationRepo, followRepo repositories.FollowRepo, events EventService, redisClient *redis.Client) *NotificationServiceImpl {
+	return &NotificationServiceImpl{
+		repo: repo,
+		followRepo: followRepo,
+		events: events,
+		redisClient: redisClient,
+	}
+}
+
+var _ NotificationService = (*NotificationServiceImpl)(nil)
+
+// Notify delivers one notification to each user who has not opted out of
+// notificationType.
+func (s *NotificationServiceImpl) Notify(ctx context.Context, notificationType string, payload models.NotificationPayload, userIDs ...uint) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.Notify")
+	defer span.End()
+	if !slices.Contains(models.NotificationTypes, notificationType) {
+		return ErrUnknownNotificationType
+	}
+	optedOut, err := s.repo.OptedOut(ctx, notificationType, userIDs)
+	if err != nil {
+		return err
+	}
+	notifications := make([]models.Notification, 0, len(userIDs))
+	for _, id := range userIDs {
+		if optedOut[id] {
+			continue
+		}
+		notifications = append(notifications, models.Notification{UserID: id, Type: notificationType, Payload: payload})
+	}
+	if err := s.repo.Create(ctx, notifications); err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		s.redisClient.Del(ctx, unreadCountKey(n.UserID))
+		// The inbox is the source of truth; a missed push is picked up on the next fetch.
+		if err := s.events.PublishToUser(ctx, models.EventNotification, notificationResp(n), n.UserID); err != nil {
+			slog.ErrorContext(ctx, "Notification service Notify error, push event", "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *NotificationServiceImpl) NotifyNewBook(ctx context.Context, book *models.Book) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.NotifyNewBook")
+	defer span.End()
+	followers, err := s.followRepo.Followers(ctx, book.AuthorID)
+	if err != nil {
+		return err
+	}
+	return s.Notify(ctx, models.NotificationNewBook, models.NotificationPayload{
+		"book_id": book.ID,
+		"author_id": book.AuthorID,
+		"title": book.Title,
+	}, followers...)
+}
+
+// GetInbox caches only the unread count, which every client polls; the list
+// itself changes on each read and is served from the database.
+func (s *NotificationServiceImpl) GetInbox(ctx context.Context, userID, limit, page uint, unreadOnly bool) (*models.NotificationInbox, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.GetInbox")
+	defer span.End()
+	p, err := s.repo.List(ctx, userID, unreadOnly, &models.Pagination{Limit: limit, Page: page, Sort: "created_at desc, id desc"})
+	if err != nil {
+		return nil, err
+	}
+	rows := p.Rows.([]models.Notification)
+	notifications := make([]models.NotificationResp, 0, len(rows))
+	for _, n := range rows {
+		notifications = append(notifications, notificationResp(n))
+	}
+	p.Rows = notifications
+
+	unread, err := s.redisClient.Get(ctx, unreadCountKey(userID)).Int64()
+	if err != nil {
+		unread, err = s.repo.CountUnread(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		s.redisClient.Set(ctx, unreadCountKey(userID), unread, 5 * time.Minute)
+	}
+	return &models.NotificationInbox{UnreadCount: unread, Notifications: p}, nil
+}
+
+func (s *NotificationServiceImpl) MarkRead(ctx context.Context, userID, id uint) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkRead")
+	defer span.End()
+	if err := s.repo.MarkRead(ctx, userID, id); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, unreadCountKey(userID))
+	return nil
+}
+
+func (s *NotificationServiceImpl) MarkAllRead(ctx context.Context, userID uint) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkAllRead")
+	defer span.End()
+	if err := s.repo.MarkAllRead(ctx, userID); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, unreadCountKey(userID))
+	return nil
+}
+
+// GetPreferences reports every known type, defaulting to enabled.
+func (s *NotificationServiceImpl) GetPreferences(ctx context.Context, userID uint) (map[string]bool, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.GetPreferences")
+	defer span.End()
+	stored, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := make(map[string]bool, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		prefs[t] = true
+	}
+	for _, p := range stored {
+		if _, ok := prefs[p.Type]; ok {
+			prefs[p.Type] = p.Enabled
+		}
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences changes only the types present in prefs and returns the
+// full resulting set.
+func (s *NotificationServiceImpl) UpdatePreferences(ctx context.Context, userID uint, prefs map[string]bool) (map[string]bool, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.UpdatePreferences")
+	defer span.End()
+	rows := make([]models.NotificationPreference, 0, len(prefs))
+	for t, enabled := range prefs {
+		if !slices.Contains(models.NotificationTypes, t) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownNotificationType, t)
+		}
+		rows = append(rows, models.NotificationPreference{UserID: userID, Type: t, Enabled: enabled})
+	}
+	if err := s.repo.SetPreferences(ctx, rows); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+func notificationResp(n models.Notification) models.NotificationResp {
+	return models.NotificationResp{
+		ID: n.ID,
+		Type: n.Type,
+		Payload: n.Payload,
+		Read: n.ReadAt != nil,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+func unreadCountKey(userID uint) string {
+	return fmt.Sprintf("notifications:unread:%d", userID)
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	PageUnitChars = "chars"
+	PageUnitWords = "words"
+)
+
+var (
+	ErrInvalidPageUnit = errors.New("page unit must be chars or words")
+	ErrInvalidPageSize = errors.New("page size is out of bounds")
+	ErrPageOutOfRange  = errors.New("page is out of range")
+)
+
+type PaginatorService interface {
+	GetPage(ctx context.Context, bookID, size uint, unit string, page uint) (*models.BookPage, error)
+	GetPageAtOffset(ctx context.Context, bookID, size uint, unit string, offset int) (*models.BookPage, error)
+}
+
+type PaginatorServiceImpl struct {
+	repo        repositories.BookRepo
+	redisClient *redis.Client
+	defaultSize uint
+	defaultUnit string
+	minSize     uint
+	maxSize     uint
+}
+
+// NewPaginatorService takes the bounds of the page size a client may ask
+// for; every size gets its own cached index, so the range is kept narrow.
+func NewPaginatorService(repo repositories.BookRepo, redisClient *redis.Client, defaultSize uint, defaultUnit string, minSize, maxSize uint) *PaginatorServiceImpl {
+	if minSize == 0 {
+		minSize = 100
+	}
+	if maxSize == 0 {
+		maxSize = 20000
+	}
+	if maxSize < minSize {
+		maxSize = minSize
+	}
+	if defaultSize == 0 {
+		defaultSize = 2000
+	}
+	defaultSize = min(max(defaultSize, minSize), maxSize)
+	if defaultUnit == "" {
+		defaultUnit = PageUnitChars
+	}
+	return &PaginatorServiceImpl{
+		repo:        repo,
+		redisClient: redisClient,
+		defaultSize: defaultSize,
+		defaultUnit: defaultUnit,
+		minSize:     minSize,
+		maxSize:     maxSize,
+	}
+}
+
+var _ PaginatorService = (*PaginatorServiceImpl)(nil)
+
+func (s *PaginatorServiceImpl) GetPage(ctx context.Context, bookID, size uint, unit string, page uint) (*models.BookPage, error) {
+	ctx, span := tracing.Start(ctx, "PaginatorService.GetPage")
+	defer span.End()
+	book, index, err := s.pageIndex(ctx, bookID, size, unit)
+	if err != nil {
+		return nil, err
+	}
+	if page == 0 {
+		page = 1
+	}
+	if page > uint(len(index.Pages)) {
+		return nil, ErrPageOutOfRange
+	}
+	return buildPage(book, index, page), nil
+}
+
+func (s *PaginatorServiceImpl) GetPageAtOffset(ctx context.Context, bookID, size uint, unit string, offset int) (*models.BookPage, error) {
+	ctx, span := tracing.Start(ctx, "PaginatorService.GetPageAtOffset")
+	defer span.End()
+	book, index, err := s.pageIndex(ctx, bookID, size, unit)
+	if err != nil {
+		return nil, err
+	}
+	page := PageForOffset(index.Pages, offset)
+	if page == 0 {
+		return nil, ErrPageOutOfRange
+	}
+	return buildPage(book, index, page), nil
+}
+
+func (s *PaginatorServiceImpl) pageIndex(ctx context.Context, bookID, size uint, unit string) (*models.Book, *models.PageIndex, error) {
+	if size == 0 {
+		size = s.defaultSize
+	}
+	if size < s.minSize || size > s.maxSize {
+		return nil, nil, fmt.Errorf("%w: size must be between %d and %d", ErrInvalidPageSize, s.minSize, s.maxSize)
+	}
+	if unit == "" {
+		unit = s.defaultUnit
+	}
+	if unit != PageUnitChars && unit != PageUnitWords {
+		return nil, nil, ErrInvalidPageUnit
+	}
+	book, err := s.repo.GetByID(ctx, bookID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The index is keyed by book revision, so an update never serves stale offsets.
+	cacheKey := fmt.Sprintf("book_pages:%d:v%d:%s:%d", book.ID, book.Version, unit, size)
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
+	if err == nil && cachedData != "" {
+		var index models.PageIndex
+		if err := json.Unmarshal([]byte(cachedData), &index); err == nil {
+			return book, &index, nil
+		}
+	}
+
+	index := &models.PageIndex{
+		BookID:  book.ID,
+		Version: book.Version,
+		Unit:    unit,
+		Size:    size,
+		Pages:   SplitPages(book.Content, size, unit),
+	}
+	data, err := json.Marshal(index)
+	if err == nil {
+		s.redisClient.Set(ctx, cacheKey, data, 24*time.Hour)
+		slog.DebugContext(ctx, "Cached book page index")
+	}
+	return book, index, nil
+}
+
+func buildPage(book *models.Book, index *models.PageIndex, page uint) *models.BookPage {
+	span := index.Pages[page-1]
+	return &models.BookPage{
+		BookID:     book.ID,
+		Version:    book.Version,
+		Page:       page,
+		TotalPages: uint(len(index.Pages)),
+		Unit:       index.Unit,
+		Size:       index.Size,
+		Start:      span.Start,
+		End:        span.End,
+		Text:       book.Content[span.Start:span.End],
+	}
+}
+
+// PageForOffset returns the 1-based page containing the byte offset, or 0 if
+// the offset is past the end of the text. Offsets that fall into whitespace
+// between two pages belong to the following page.
+func PageForOffset(pages []models.PageSpan, offset int) uint {
+	if offset < 0 {
+		offset = 0
+	}
+	i := sort.Search(len(pages), func(i int) bool { return pages[i].End > offset })
+	if i == len(pages) {
+		return 0
+	}
+	return uint(i + 1)
+}
+
+// SplitPages breaks content into pages of at most size characters or words.
+// Whole paragraphs are kept together where they fit, long paragraphs are cut
+// at sentence ends and only a sentence longer than a page is cut between words.
+// Returned spans are byte offsets into content.
+func SplitPages(content string, size uint, unit string) []models.PageSpan {
+	measure := func(s string) int { return utf8.RuneCountInString(s) }
+	if unit == PageUnitWords {
+		measure = func(s string) int { return len(strings.Fields(s)) }
+	}
+	budget := int(size)
+	if budget <= 0 {
+		budget = 1
+	}
+
+	var atoms []models.PageSpan
+	for _, paragraph := range paragraphSpans(content) {
+		if measure(content[paragraph.Start:paragraph.End]) <= budget {
+			atoms = append(atoms, paragraph)
+			continue
+		}
+		for _, sentence := range sentenceSpans(content, paragraph) {
+			if measure(content[sentence.Start:sentence.End]) <= budget {
+				atoms = append(atoms, sentence)
+				continue
+			}
+			atoms = append(atoms, wordChunks(content, sentence, budget, measure)...)
+		}
+	}
+
+	var pages []models.PageSpan
+	for _, atom := range atoms {
+		if len(pages) > 0 {
+			last := &pages[len(pages)-1]
+			if measure(content[last.Start:atom.End]) <= budget {
+				last.End = atom.End
+				continue
+			}
+		}
+		pages = append(pages, atom)
+	}
+	return pages
+}
+
+func paragraphSpans(content string) []models.PageSpan {
+	var spans []models.PageSpan
+	start := -1
+	for i := 0; i < len(content); {
+		if content[i] == '\n' {
+			j := i + 1
+			for j < len(content) && (content[j] == ' ' || content[j] == '\t' || content[j] == '\r') {
+				j++
+			}
+			if j < len(content) && content[j] == '\n' {
+				if start >= 0 {
+					spans = append(spans, trimSpan(content, models.PageSpan{Start: start, End: i}))
+					start = -1
+				}
+				i = j + 1
+				continue
+			}
+		}
+		if start < 0 && !isSpaceByte(content[i]) {
+			start = i
+		}
+		i++
+	}
+	if start >= 0 {
+		spans = append(spans, trimSpan(content, models.PageSpan{Start: start, End: len(content)}))
+	}
+	return spans
+}
+
+func sentenceSpans(content string, paragraph models.PageSpan) []models.PageSpan {
+	var spans []models.PageSpan
+	start := paragraph.Start
+	for i := paragraph.Start; i < paragraph.End; {
+		r, width := utf8.DecodeRuneInString(content[i:paragraph.End])
+		i += width
+		if r != '.' && r != '!' && r != '?' && r != '…' {
+			continue
+		}
+		end := i
+		for end < paragraph.End {
+			next, w := utf8.DecodeRuneInString(content[end:paragraph.End])
+			if next != '.' && next != '!' && next != '?' && next != '"' && next != '\'' && next != '»' && next != ')' && next != '”' {
+				break
+			}
+			end += w
+		}
+		if end == paragraph.End {
+			break
+		}
+		next, _ := utf8.DecodeRuneInString(content[end:paragraph.End])
+		if !unicode.IsSpace(next) {
+			i = end
+			continue
+		}
+		spans = append(spans, models.PageSpan{Start: start, End: end})
+		start = end
+		for start < paragraph.End && isSpaceByte(content[start]) {
+			start++
+		}
+		i = start
+	}
+	if start < paragraph.End {
+		spans = append(spans, models.PageSpan{Start: start, End: paragraph.End})
+	}
+	return spans
+}
+
+func wordChunks(content string, span models.PageSpan, budget int, measure func(string) int) []models.PageSpan {
+	var chunks []models.PageSpan
+	chunk := models.PageSpan{Start: -1}
+	for i := span.Start; i < span.End; {
+		for i < span.End && isSpaceByte(content[i]) {
+			i++
+		}
+		if i == span.End {
+			break
+		}
+		wordEnd := i
+		for wordEnd < span.End && !isSpaceByte(content[wordEnd]) {
+			wordEnd++
+		}
+		switch {
+		case chunk.Start >= 0 && measure(content[chunk.Start:wordEnd]) <= budget:
+			chunk.End = wordEnd
+		case measure(content[i:wordEnd]) <= budget:
+			if chunk.Start >= 0 {
+				chunks = append(chunks, chunk)
+			}
+			chunk = models.PageSpan{Start: i, End: wordEnd}
+		default:
+			if chunk.Start >= 0 {
+				chunks = append(chunks, chunk)
+			}
+			chunks = append(chunks, runeChunks(content, models.PageSpan{Start: i, End: wordEnd}, budget)...)
+			chunk = models.PageSpan{Start: -1}
+		}
+		i = wordEnd
+	}
+	if chunk.Start >= 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func runeChunks(content string, span models.PageSpan, budget int) []models.PageSpan {
+	var chunks []models.PageSpan
+	start, count := span.Start, 0
+	for i := span.Start; i < span.End; {
+		_, width := utf8.DecodeRuneInString(content[i:span.End])
+		if count == budget {
+			chunks = append(chunks, models.PageSpan{Start: start, End: i})
+			start, count = i, 0
+		}
+		i += width
+		count++
+	}
+	return append(chunks, models.PageSpan{Start: start, End: span.End})
+}
+
+func trimSpan(content string, span models.PageSpan) models.PageSpan {
+	for span.Start < span.End && isSpaceByte(content[span.Start]) {
+		span.Start++
+	}
+	for span.End > span.Start && isSpaceByte(content[span.End-1]) {
+		span.End--
+	}
+	return span
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\t' || b == '\r'
+}
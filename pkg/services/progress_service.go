@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"gorm.io/gorm"
+)
+
+// maxClockSkew bounds how far in the future a device timestamp may be. A
+// device with a fast clock would otherwise win every conflict.
+const maxClockSkew = time.Minute
+
+var ErrNoProgress = errors.New("no reading progress for this book")
+
+type ProgressService interface {
+	GetProgress(ctx context.Context, userID, bookID uint) (*models.ProgressResp, error)
+	UpdateProgress(ctx context.Context, userID, bookID uint, deviceID string, req *models.ProgressReq) (*models.ProgressResp, bool, error)
+	Watch(ctx context.Context, userID, bookID uint, deviceID string) (<-chan models.ProgressResp, error)
+}
+
+type ProgressServiceImpl struct {
+	repo repositories.ProgressRepo
+	bookRepo repositories.BookRepo
+	events EventService
+}
+
+func NewProgressService(repo repositories.ProgressRepo, bookRepo repositories.BookRepo, events EventService) *ProgressServiceImpl {
+	return &ProgressServiceImpl{
+		repo: repo,
+		bookRepo: bookRepo,
+		events: events,
+	}
+}
+
+var _ ProgressService = (*ProgressServiceImpl)(nil)
+
+func (s *ProgressServiceImpl) GetProgress(ctx context.Context, userID, bookID uint) (*models.ProgressResp, error) {
+	ctx, span := tracing.Start(ctx, "ProgressService.GetProgress")
+	defer span.End()
+	if _, err := s.bookRepo.GetByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	progress, err := s.repo.Get(ctx, userID, bookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	return progressResp(progress), nil
+}
+
+// UpdateProgress applies the position if it is the newest one seen and tells
+// the user's other devices. Otherwise it returns the stored position, which
+// the device should jump to, and false.
+func (s *ProgressServiceImpl) UpdateProgress(ctx context.Context, userID, bookID uint, deviceID string, req *models.ProgressReq) (*models.ProgressResp, bool, error) {
+	ctx, span := tracing.Start(ctx, "ProgressService.UpdateProgress")
+	defer span.End()
+	if _, err := s.bookRepo.GetByID(ctx, bookID); err != nil {
+		return nil, false, err
+	}
+	now := time.Now().UTC()
+	updatedAt := req.UpdatedAt.UTC()
+	if updatedAt.IsZero() || updatedAt.After(now.Add(maxClockSkew)) {
+		updatedAt = now
+	}
+	progress := &models.ReadingProgress{
+		UserID: userID,
+		BookID: bookID,
+		Position: req.Position,
+		Page: req.Page,
+		DeviceID: deviceID,
+		ClientUpdatedAt: updatedAt,
+	}
+	applied, err := s.repo.Save(ctx, progress)
+	if err != nil {
+		return nil, false, err
+	}
+	if !applied {
+		current, err := s.repo.Get(ctx, userID, bookID)
+		if err != nil {
+			return nil, false, err
+		}
+		return progressResp(current), false, nil
+	}
+	resp := progressResp(progress)
+	if err := s.events.PublishToUser(ctx, models.EventReadingProgress, resp, userID); err != nil {
+		slog.ErrorContext(ctx, "Progress service UpdateProgress error, push event", "error", err)
+	}
+	return resp, true, nil
+}
+
+// Watch streams positions for bookID recorded by the user's other devices.
+func (s *ProgressServiceImpl) Watch(ctx context.Context, userID, bookID uint, deviceID string) (<-chan models.ProgressResp, error) {
+	ctx, span := tracing.Start(ctx, "ProgressService.Watch")
+	defer span.End()
+	stream, err := s.events.Subscribe(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan models.ProgressResp)
+	go func() {
+		defer close(out)
+		for e := range stream {
+			if e.Type != models.EventReadingProgress {
+				continue
+			}
+			var p models.ProgressResp
+			if err := json.Unmarshal(e.Data, &p); err != nil || p.BookID != bookID || p.DeviceID == deviceID {
+				continue
+			}
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func progressResp(p *models.ReadingProgress) *models.ProgressResp {
+	return &models.ProgressResp{
+		BookID: p.BookID,
+		Position: p.Position,
+		Page: p.Page,
+		DeviceID: p.DeviceID,
+		UpdatedAt: p.ClientUpdatedAt,
+	}
+}
@@ -0,0 +1,90 @@
+package services_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deletedKeys перехватывает команды Redis и запоминает ключи из DEL.
+type deletedKeys struct {
+	keys []string
+}
+
+func (h *deletedKeys) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *deletedKeys) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "del" {
+			for _, arg := range cmd.Args()[1:] {
+				h.keys = append(h.keys, arg.(string))
+			}
+		}
+		return nil
+	}
+}
+
+func (h *deletedKeys) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func recordingRedis() (*redis.Client, *deletedKeys) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	hook := &deletedKeys{}
+	client.AddHook(hook)
+	return client, hook
+}
+
+// editions — книги с известной работой, которые можно менять и удалять.
+type editions struct {
+	repositories.BookRepo
+	works map[uint]*uint
+}
+
+func (r *editions) GetWorkID(ctx context.Context, id uint) (*uint, error) {
+	return r.works[id], nil
+}
+
+func (r *editions) IsBelongsTo(ctx context.Context, id uint, authorID uint) (bool, error) {
+	return true, nil
+}
+
+func (r *editions) Update(ctx context.Context, book *models.Book, id uint, version uint) error {
+	return nil
+}
+
+func (r *editions) Delete(ctx context.Context, id uint) error {
+	return nil
+}
+
+func TestBookService_InvalidatesWork(t *testing.T) {
+	work := uint(5)
+	repo := &editions{works: map[uint]*uint{1: &work, 2: nil}}
+	client, hook := recordingRedis()
+	svc := services.NewBookService(repo, nil, client, 200, nil)
+
+	// Издание работы: вместе с книгой сбрасывается и список изданий
+	require.NoError(t, svc.UpdateBook(context.Background(), &models.Book{Title: "Emma"}, 1, 7, 1))
+	assert.Equal(t, []string{"book:1", "work:5"}, hook.keys)
+
+	hook.keys = nil
+	require.NoError(t, svc.UnpublishBook(context.Background(), 1))
+	assert.Equal(t, []string{"book:1", "work:5"}, hook.keys)
+
+	// Книга без работы
+	hook.keys = nil
+	require.NoError(t, svc.UnpublishBook(context.Background(), 2))
+	assert.Equal(t, []string{"book:2"}, hook.keys)
+}
@@ -0,0 +1,51 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// importedBooks keeps books by the job that created them.
+type importedBooks struct {
+	repositories.BookRepo
+	byJob map[string]models.Book
+}
+
+func (r *importedBooks) CreateImported(ctx context.Context, book *models.Book, jobID string) error {
+	book.ID = uint(len(r.byJob) + 1)
+	r.byJob[jobID] = *book
+	return nil
+}
+
+func (r *importedBooks) GetImported(ctx context.Context, jobID string) (*models.Book, error) {
+	if b, ok := r.byJob[jobID]; ok {
+		return &b, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestImportBook_Idempotent(t *testing.T) {
+	repo := &importedBooks{byJob: map[string]models.Book{}}
+	svc := services.NewBookService(repo, nil, nil, 200, nil)
+
+	first := &models.Book{Title: "Emma", Content: "Emma Woodhouse, handsome, clever, and rich.", AuthorID: 7}
+	require.NoError(t, svc.ImportBook(context.Background(), first, "job-1"))
+	assert.Equal(t, uint(1), first.ID)
+	assert.Equal(t, "und", first.Language)
+
+	// Повторный запуск той же задачи возвращает ту же книгу
+	again := &models.Book{Title: "Emma", Content: "Emma Woodhouse, handsome, clever, and rich.", AuthorID: 7}
+	require.NoError(t, svc.ImportBook(context.Background(), again, "job-1"))
+	assert.Equal(t, uint(1), again.ID)
+	assert.Len(t, repo.byJob, 1)
+
+	assert.Error(t, svc.ImportBook(context.Background(), &models.Book{Title: "Emma", Content: "Emma Woodhouse."}, ""))
+}
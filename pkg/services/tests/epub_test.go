@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildEPUB(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, body := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseEPUB(t *testing.T) {
+	data := buildEPUB(t, map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container xmlns="urn:oasis:names:tc:opendocument:xmlns:container" version="1.0">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>Белые ночи</dc:title>
+    <dc:language>ru</dc:language>
+  </metadata>
+  <manifest>
+    <item id="c2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="c1"/><itemref idref="c2"/></spine>
+</package>`,
+		"OEBPS/text/ch1.xhtml": `<html><head><title>skip</title><style>p{}</style></head>
+<body><h1>Ночь первая</h1><p>Была чудная ночь,&nbsp;такая   ночь,<br/>которая разве только может быть.</p></body></html>`,
+		"OEBPS/text/ch2.xhtml": `<html><body><p>Ночь вторая</p></body></html>`,
+	})
+
+	book, err := services.ParseEPUB(data)
+	require.NoError(t, err)
+	assert.Equal(t, "Белые ночи", book.Title)
+	assert.Equal(t, "ru", book.Language)
+	// Главы идут в порядке spine, а не manifest; head и style отброшены
+	assert.Equal(t, "Ночь первая\n\nБыла чудная ночь, такая ночь, которая разве только может быть.\n\nНочь вторая", book.Content)
+}
+
+func TestParseEPUBInvalid(t *testing.T) {
+	_, err := services.ParseEPUB([]byte("not a zip"))
+	assert.ErrorIs(t, err, services.ErrInvalidEPUB)
+
+	// Архив без container.xml
+	_, err = services.ParseEPUB(buildEPUB(t, map[string]string{"mimetype": "application/epub+zip"}))
+	assert.ErrorIs(t, err, services.ErrInvalidEPUB)
+}
@@ -0,0 +1,33 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedCursor_RoundTrip(t *testing.T) {
+	cursor := models.FeedCursor{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC), ID: 42}
+
+	decoded, err := services.DecodeFeedCursor(services.EncodeFeedCursor(cursor))
+
+	assert.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestFeedCursor_Invalid(t *testing.T) {
+	// Пустой курсор означает первую страницу
+	decoded, err := services.DecodeFeedCursor("")
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	for _, cursor := range []string{"not base64!", "bm9jb2xvbg", "YWJjOjEy"} {
+		_, err := services.DecodeFeedCursor(cursor)
+		assert.ErrorIs(t, err, services.ErrInvalidCursor, cursor)
+	}
+}
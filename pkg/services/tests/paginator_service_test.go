@@ -0,0 +1,73 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pageTexts(content string, pages []models.PageSpan) []string {
+	texts := make([]string, 0, len(pages))
+	for _, p := range pages {
+		texts = append(texts, content[p.Start:p.End])
+	}
+	return texts
+}
+
+func TestSplitPages_KeepsParagraphsTogether(t *testing.T) {
+	content := "First paragraph here.\n\nSecond paragraph here.\n\nThird one."
+
+	pages := services.SplitPages(content, 50, services.PageUnitChars)
+
+	assert.Equal(t, []string{
+		"First paragraph here.\n\nSecond paragraph here.",
+		"Third one.",
+	}, pageTexts(content, pages))
+}
+
+func TestSplitPages_SplitsLongParagraphBySentences(t *testing.T) {
+	content := "One two three. Four five six! Seven eight nine? Ten."
+
+	pages := services.SplitPages(content, 3, services.PageUnitWords)
+
+	assert.Equal(t, []string{
+		"One two three.",
+		"Four five six!",
+		"Seven eight nine?",
+		"Ten.",
+	}, pageTexts(content, pages))
+}
+
+func TestSplitPages_SplitsLongSentenceByWords(t *testing.T) {
+	content := "Привет мир это очень длинное предложение без точек"
+
+	pages := services.SplitPages(content, 20, services.PageUnitChars)
+
+	for _, text := range pageTexts(content, pages) {
+		// Страница не длиннее бюджета и не режет слова
+		assert.LessOrEqual(t, utf8.RuneCountInString(text), 20)
+		assert.Equal(t, strings.TrimSpace(text), text)
+	}
+	assert.Equal(t, strings.Fields(content), strings.Fields(strings.Join(pageTexts(content, pages), " ")))
+}
+
+func TestSplitPages_EmptyContent(t *testing.T) {
+	assert.Empty(t, services.SplitPages("  \n\n ", 100, services.PageUnitChars))
+}
+
+func TestPageForOffset(t *testing.T) {
+	pages := []models.PageSpan{{Start: 0, End: 10}, {Start: 12, End: 20}}
+
+	assert.Equal(t, uint(1), services.PageForOffset(pages, 0))
+	assert.Equal(t, uint(1), services.PageForOffset(pages, 9))
+	// Смещение в пробелах между страницами относится к следующей странице
+	assert.Equal(t, uint(2), services.PageForOffset(pages, 10))
+	assert.Equal(t, uint(2), services.PageForOffset(pages, 19))
+	assert.Equal(t, uint(0), services.PageForOffset(pages, 20))
+	assert.Equal(t, uint(1), services.PageForOffset(pages, -5))
+}
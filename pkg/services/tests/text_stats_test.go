@@ -0,0 +1,41 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeTextStats(t *testing.T) {
+	content := "The cat sat on the mat. It was happy.\n\nThen it slept."
+
+	stats := services.ComputeTextStats(content, 200)
+
+	assert.Equal(t, 12, stats.WordCount)
+	assert.Equal(t, len(content), stats.CharCount)
+	assert.Equal(t, 2, stats.ParagraphCount)
+	assert.Equal(t, 1, stats.ReadingMinutes)
+	// Короткие предложения из односложных слов читаются легко
+	assert.Greater(t, stats.Readability, 90.0)
+	assert.LessOrEqual(t, stats.Readability, 100.0)
+}
+
+func TestComputeTextStats_ReadingTime(t *testing.T) {
+	content := strings.Repeat("слово ", 1000)
+
+	assert.Equal(t, 5, services.ComputeTextStats(content, 200).ReadingMinutes)
+	assert.Equal(t, 4, services.ComputeTextStats(content, 250).ReadingMinutes)
+	// Скорость по умолчанию, если в конфиге ничего не задано
+	assert.Equal(t, 5, services.ComputeTextStats(content, 0).ReadingMinutes)
+}
+
+func TestComputeTextStats_Empty(t *testing.T) {
+	stats := services.ComputeTextStats("   ", 200)
+
+	assert.Zero(t, stats.WordCount)
+	assert.Zero(t, stats.ReadingMinutes)
+	assert.Zero(t, stats.Readability)
+}
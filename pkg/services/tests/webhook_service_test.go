@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/services"
+	"github.com/Quavke/eBookReader/pkg/webhooks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedDeliveries struct {
+	repositories.WebhookRepo
+	url    string
+	mu     sync.Mutex
+	logged []models.WebhookDelivery
+}
+
+func (r *recordedDeliveries) ListSubscribed(ctx context.Context, event string, authorID uint) ([]models.Webhook, error) {
+	return []models.Webhook{{URL: r.url, Secret: "secret", Active: true}}, nil
+}
+
+func (r *recordedDeliveries) LogDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logged = append(r.logged, *delivery)
+	return nil
+}
+
+func (r *recordedDeliveries) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.logged)
+}
+
+func TestWebhookEmit_ShutdownAbandonsRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repo := &recordedDeliveries{url: server.URL}
+	ctx, cancel := context.WithCancel(context.Background())
+	var deliveries sync.WaitGroup
+	svc := services.NewWebhookService(repo, nil, webhooks.NewSender(server.Client()), ctx, &deliveries, nil)
+
+	book := &models.Book{AuthorID: 7}
+	book.ID = 1
+	svc.Emit(context.Background(), "evt1-deleted", models.WebhookBookDeleted, book)
+	assert.Eventually(t, func() bool { return repo.count() == 1 }, 3*time.Second, 10*time.Millisecond)
+
+	// Доставка ждёт повтора и учитывается, пока не отменена
+	waited := make(chan struct{})
+	go func() {
+		deliveries.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("delivery was not tracked while waiting for a retry")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-waited:
+	case <-time.After(3 * time.Second):
+		t.Fatal("pending retry kept running after cancel")
+	}
+	assert.Equal(t, 1, repo.count())
+}
@@ -0,0 +1,28 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchEdition(t *testing.T) {
+	editions := []models.EditionResp{
+		{BookID: 1, Language: "ru", Original: true},
+		{BookID: 2, Language: "en"},
+		{BookID: 3, Language: "pt-BR"},
+	}
+
+	// Без заголовка отдаём оригинал
+	assert.Equal(t, 0, services.MatchEdition(editions, 1, ""))
+	assert.Equal(t, 1, services.MatchEdition(editions, 1, "en-US,en;q=0.9"))
+	// Региональный вариант подходит к более общему запросу
+	assert.Equal(t, 2, services.MatchEdition(editions, 1, "pt"))
+	assert.Equal(t, 1, services.MatchEdition(editions, 1, "de, en;q=0.5"))
+	// Ни один язык не подходит: возвращаемся к оригиналу, даже если он не первый
+	assert.Equal(t, 1, services.MatchEdition([]models.EditionResp{{BookID: 2, Language: "en"}, {BookID: 1, Language: "ru"}}, 1, "ja"))
+	assert.Equal(t, -1, services.MatchEdition(nil, 1, "en"))
+}
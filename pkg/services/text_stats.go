@@ -0,0 +1,66 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+const defaultWordsPerMinute = 230
+
+// ComputeTextStats counts words, characters and paragraphs of content, estimates
+// the reading time for the given reading speed and scores readability with the
+// Flesch reading-ease formula (0 is hardest, 100 is easiest).
+func ComputeTextStats(content string, wordsPerMinute uint) models.TextStats {
+	if wordsPerMinute == 0 {
+		wordsPerMinute = defaultWordsPerMinute
+	}
+	words := strings.Fields(content)
+	stats := models.TextStats{
+		WordCount:      len(words),
+		CharCount:      utf8.RuneCountInString(content),
+		ParagraphCount: len(paragraphSpans(content)),
+	}
+	if stats.WordCount == 0 {
+		return stats
+	}
+	stats.ReadingMinutes = int(math.Ceil(float64(stats.WordCount) / float64(wordsPerMinute)))
+
+	sentences := 0
+	for _, paragraph := range paragraphSpans(content) {
+		sentences += len(sentenceSpans(content, paragraph))
+	}
+	syllables := 0
+	for _, word := range words {
+		syllables += countSyllables(word)
+	}
+	score := 206.835 -
+		1.015*float64(stats.WordCount)/float64(sentences) -
+		84.6*float64(syllables)/float64(stats.WordCount)
+	stats.Readability = math.Round(math.Max(0, math.Min(100, score))*10) / 10
+	return stats
+}
+
+// countSyllables approximates syllables as groups of vowels, which works for
+// both Latin and Cyrillic scripts.
+func countSyllables(word string) int {
+	count, inVowel := 0, false
+	for _, r := range strings.ToLower(word) {
+		vowel := strings.ContainsRune("aeiouyаеёиоуыэюя", r)
+		if vowel && !inVowel {
+			count++
+		}
+		inVowel = vowel
+	}
+	if count == 0 {
+		for _, r := range word {
+			if unicode.IsLetter(r) {
+				return 1
+			}
+		}
+	}
+	return count
+}
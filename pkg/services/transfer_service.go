@@ -0,0 +1,220 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Quavke/eBookReader/pkg/jobs"
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/Quavke/eBookReader/pkg/storage"
+	"gorm.io/gorm"
+)
+
+var ErrNothingToExport = errors.New("no books to export")
+
+// exportPageSize is how many of an author's books are loaded at a time.
+const exportPageSize = 100
+
+// TransferService moves books in and out in bulk. Requests only store the
+// input and enqueue a job; the Run methods are the job handlers.
+type TransferService interface {
+	ImportEPUB(ctx context.Context, ownerID uint, data []byte) (*models.JobResp, error)
+	ExportBooks(ctx context.Context, ownerID uint, req *models.ExportReq) (*models.JobResp, error)
+	RunImport(ctx context.Context, ownerID uint, job models.ImportJob) (any, error)
+	RunExport(ctx context.Context, ownerID uint, job models.ExportJob) (any, error)
+}
+
+type TransferServiceImpl struct {
+	jobs JobService
+	books BookService
+	bookRepo repositories.BookRepo
+	store storage.BlobStore
+}
+
+func NewTransferService(jobs JobService, books BookService, bookRepo repositories.BookRepo, store storage.BlobStore) *TransferServiceImpl {
+	return &TransferServiceImpl{
+		jobs: jobs,
+		books: books,
+		bookRepo: bookRepo,
+		store: store,
+	}
+}
+
+var _ TransferService = (*TransferServiceImpl)(nil)
+
+// ImportEPUB only checks that data is a zip archive; the EPUB itself is
+// parsed by the job, whose status reports a malformed file.
+func (s *TransferServiceImpl) ImportEPUB(ctx context.Context, ownerID uint, data []byte) (*models.JobResp, error) {
+	ctx, span := tracing.Start(ctx, "TransferService.ImportEPUB")
+	defer span.End()
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return nil, ErrInvalidEPUB
+	}
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("imports/%d/%s.epub", ownerID, name)
+	if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "application/epub+zip"); err != nil {
+		return nil, err
+	}
+	resp, err := s.jobs.Enqueue(ctx, models.JobBookImport, ownerID, models.ImportJob{BlobKey: key, AuthorID: ownerID})
+	if err != nil {
+		s.store.Delete(context.WithoutCancel(ctx), key)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ExportBooks exports the given books, or all of the caller's books when
+// none are given.
+func (s *TransferServiceImpl) ExportBooks(ctx context.Context, ownerID uint, req *models.ExportReq) (*models.JobResp, error) {
+	ctx, span := tracing.Start(ctx, "TransferService.ExportBooks")
+	defer span.End()
+	format := req.Format
+	if format == "" {
+		format = "txt"
+	}
+	if _, ok := contentFormats[format]; !ok {
+		return nil, ErrUnsupportedFormat
+	}
+	job := models.ExportJob{BookIDs: req.BookIDs, Format: format}
+	if len(req.BookIDs) == 0 {
+		job.AuthorID = ownerID
+	}
+	return s.jobs.Enqueue(ctx, models.JobBookExport, ownerID, job)
+}
+
+func (s *TransferServiceImpl) RunImport(ctx context.Context, ownerID uint, job models.ImportJob) (any, error) {
+	ctx, span := tracing.Start(ctx, "TransferService.RunImport")
+	defer span.End()
+	rc, err := s.store.Get(ctx, job.BlobKey)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return nil, jobs.Permanent(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(rc)
+	rc.Close()
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ParseEPUB(buf.Bytes())
+	if err != nil {
+		return nil, jobs.Permanent(err)
+	}
+	book := &models.Book{
+		Title: parsed.Title,
+		Content: parsed.Content,
+		AuthorID: job.AuthorID,
+		Language: parsed.Language,
+	}
+	if err := s.books.ImportBook(ctx, book, jobs.ID(ctx)); err != nil {
+		if errors.Is(err, ErrInvalidLanguage) {
+			return nil, jobs.Permanent(err)
+		}
+		return nil, err
+	}
+	if book.ID == 0 {
+		return nil, errors.New("import created no book")
+	}
+	if err := s.store.Delete(ctx, job.BlobKey); err != nil {
+		slog.WarnContext(ctx, "Transfer service RunImport error, delete upload", "key", job.BlobKey, "error", err)
+	}
+	return models.ImportResult{BookID: book.ID, Title: book.Title}, nil
+}
+
+// RunExport writes one file per book into a zip archive in blob storage.
+// Books that no longer exist are skipped.
+func (s *TransferServiceImpl) RunExport(ctx context.Context, ownerID uint, job models.ExportJob) (any, error) {
+	ctx, span := tracing.Start(ctx, "TransferService.RunExport")
+	defer span.End()
+	books, err := s.exportedBooks(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	if len(books) == 0 {
+		return nil, jobs.Permanent(ErrNothingToExport)
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, b := range books {
+		w, err := archive.Create(fmt.Sprintf("%d.%s", b.ID, job.Format))
+		if err != nil {
+			return nil, err
+		}
+		body := []byte(b.Content)
+		if job.Format == "html" {
+			body = renderBookHTML(b.Title, b.Content)
+		}
+		if _, err := w.Write(body); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("exports/%d/%s.zip", ownerID, name)
+	if err := s.store.Put(ctx, key, &buf, int64(buf.Len()), "application/zip"); err != nil {
+		return nil, err
+	}
+	return models.ExportResult{URL: s.store.URL(key), Books: len(books)}, nil
+}
+
+func (s *TransferServiceImpl) exportedBooks(ctx context.Context, job models.ExportJob) ([]models.Book, error) {
+	var books []models.Book
+	if len(job.BookIDs) > 0 {
+		for _, id := range job.BookIDs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			b, err := s.bookRepo.GetByID(ctx, id)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			books = append(books, *b)
+		}
+		return books, nil
+	}
+	for page := uint(1); ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p, err := s.bookRepo.GetByAuthor(ctx, job.AuthorID, &models.Pagination{Limit: exportPageSize, Page: page, Sort: "id asc"})
+		if err != nil {
+			return nil, err
+		}
+		rows := p.Rows.([]models.Book)
+		books = append(books, rows...)
+		if len(rows) < exportPageSize {
+			return books, nil
+		}
+	}
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -3,46 +3,51 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/Quavke/eBookReader/pkg/models"
 	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type UserService interface {
-	GetAllUsers(limit, page uint, sort string)      (*models.Pagination, error)
-	GetUserByID(id uint) 									  				(*models.UserResp, error)
-	CreateUser(username string, pwd []byte)         error
-	LoginUser(user *models.RegisterReq) 						(*models.Claims, error)
-	UpdateUser(user *models.UpdateReq, id uint)   	error
-	DeleteUser(id uint)                      				error
+	GetAllUsers(ctx context.Context, limit, page uint, sort string)      (*models.Pagination, error)
+	GetUserByID(ctx context.Context, id uint) 									  				(*models.UserResp, error)
+	CreateUser(ctx context.Context, username string, pwd []byte)         error
+	LoginUser(ctx context.Context, user *models.RegisterReq) 						(*models.Claims, error)
+	UpdateUser(ctx context.Context, user *models.UpdateReq, id, version uint)   	error
+	DeleteUser(ctx context.Context, id uint)                      				error
+	SetAdmin(ctx context.Context, id uint, admin bool)                  error
+	ResetPassword(ctx context.Context, id uint, pwd []byte)             error
 }
 
 type UserServiceImpl struct {
 	repo repositories.UserRepo
-	context context.Context
 	redisClient *redis.Client
 }
 
-func NewUserService(repo repositories.UserRepo, context context.Context, redisClient *redis.Client) *UserServiceImpl{
+func NewUserService(repo repositories.UserRepo, redisClient *redis.Client) *UserServiceImpl{
 	return &UserServiceImpl{
 		repo: repo,
-		context: context,
 		redisClient: redisClient,
 	}
 }
 
 var _ UserService = (*UserServiceImpl)(nil)
 
-func (s UserServiceImpl) GetAllUsers(limit, page uint, sort string) (*models.Pagination, error){
+func (s UserServiceImpl) GetAllUsers(ctx context.Context, limit, page uint, sort string) (*models.Pagination, error){
+	ctx, span := tracing.Start(ctx, "UserService.GetAllUsers")
+	defer span.End()
 	cacheKey := fmt.Sprintf("users:limit=%d,page=%d,sort=%s", limit, page, sort)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 	if err == nil && cachedData != "" {
 		var p models.Pagination
 		if err := json.Unmarshal([]byte(cachedData), &p); err == nil {
@@ -54,7 +59,7 @@ func (s UserServiceImpl) GetAllUsers(limit, page uint, sort string) (*models.Pag
 		Page: page,
 		Sort: sort,
 	}
-	p, err = s.repo.GetAll(p)
+	p, err = s.repo.GetAll(ctx, p)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +70,7 @@ func (s UserServiceImpl) GetAllUsers(limit, page uint, sort string) (*models.Pag
       ids = append(ids, u.ID)
   }
 
-  isAuthor, err := s.repo.IsAuthors(ids)
+  isAuthor, err := s.repo.IsAuthors(ctx, ids)
   if err != nil {
       return nil, err
   }
@@ -73,55 +78,64 @@ func (s UserServiceImpl) GetAllUsers(limit, page uint, sort string) (*models.Pag
     users = append(users, models.UserResp{
         Username: u.Username,
         IsAuthor: isAuthor[u.ID],
+        Version: u.Version,
+        UpdatedAt: u.UpdatedAt,
     })
+    p.Touch(u.UpdatedAt)
   }
 
 	p.Rows = users
 
 	data, err := json.Marshal(p)
 	if err == nil {
-		s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-		log.Print("Cached users data")
+		s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+		slog.DebugContext(ctx, "Cached users data")
 	}
 
 	return p, nil
 }
 
-func (s *UserServiceImpl) GetUserByID(id uint) (*models.UserResp, error) {
+func (s *UserServiceImpl) GetUserByID(ctx context.Context, id uint) (*models.UserResp, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
 	cacheKey := fmt.Sprintf("user:%d", id)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 		if err == nil && cachedData != "" {
 			var user models.UserResp
 			if err := json.Unmarshal([]byte(cachedData), &user); err == nil {
 				return &user, nil
 			}
 	}
-	userDB, err := s.repo.GetByID(id)
+	userDB, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	isAuthor, err := s.repo.IsAuthor(userDB.ID)
-	if err != nil {
+	isAuthor, err := s.repo.IsAuthor(ctx, userDB.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 
 	user := &models.UserResp{
 		Username: userDB.Username,
 		IsAuthor: isAuthor,
+		Version: userDB.Version,
+		UpdatedAt: userDB.UpdatedAt,
 	}
 
 	data, err := json.Marshal(user)
 	if err == nil {
-		s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-		log.Print("Cached users data")
+		s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+		slog.DebugContext(ctx, "Cached users data")
 	}
 
 	return user, nil
 }
 
-func (s *UserServiceImpl) CreateUser(username string, pwd []byte) error{
+func (s *UserServiceImpl) CreateUser(ctx context.Context, username string, pwd []byte) error{
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
 	cacheKey := fmt.Sprintf("user:username=%s", username)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 		if err == nil && cachedData != "" {
 			var result string
 			if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
@@ -143,7 +157,7 @@ func (s *UserServiceImpl) CreateUser(username string, pwd []byte) error{
 	userDB.Username = username
 	userDB.PasswordHash = hash
 
-	createResult := s.repo.Create(&userDB)
+	createResult := s.repo.Create(ctx, &userDB)
 	var result string
 	if createResult != nil {
 		result = createResult.Error()
@@ -152,23 +166,25 @@ func (s *UserServiceImpl) CreateUser(username string, pwd []byte) error{
 	}
 	data, err := json.Marshal(result)
 	if err == nil {
-		s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-		log.Print("Cached create user data")
+		s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+		slog.DebugContext(ctx, "Cached create user data")
 	}
 
 	return createResult
 }
 
-func (s *UserServiceImpl) LoginUser(user *models.RegisterReq) (*models.Claims, error) {
+func (s *UserServiceImpl) LoginUser(ctx context.Context, user *models.RegisterReq) (*models.Claims, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginUser")
+	defer span.End()
 	cacheKey := fmt.Sprintf("login_user:username=%s", user.Username)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 		if err == nil && cachedData != "" {
 			var claims models.Claims
 			if err := json.Unmarshal([]byte(cachedData), &claims); err == nil {
 				return &claims, nil
 			}
 	}
-	userDB, err := s.repo.GetByUsername(user.Username)
+	userDB, err := s.repo.GetByUsername(ctx, user.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -188,43 +204,28 @@ func (s *UserServiceImpl) LoginUser(user *models.RegisterReq) (*models.Claims, e
   }
 	data, err := json.Marshal(claims)
 	if err == nil {
-		s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-		log.Print("Cached login user data")
+		s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+		slog.DebugContext(ctx, "Cached login user data")
 	}
 	return claims, nil
 }
 
-func (s *UserServiceImpl) UpdateUser(user *models.UpdateReq, id uint) error{
-	cacheKey := fmt.Sprintf("update_user:%d,username=%s", id, user.Username)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
-		if err == nil && cachedData != "" {
-			var result string
-			if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
-				if result == "success" {
-					return nil
-				} else {
-					return fmt.Errorf("cached error: %s", result)
-				}
-			}
-	}
-	updateResult := s.repo.Update(user, id)
-	var result string
-	if updateResult != nil {
-		result = updateResult.Error()
-	} else {
-		result = "success"
-	}
-	data, err := json.Marshal(result)
-	if err == nil {
-		s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-		log.Print("Cached update user data")
+func (s *UserServiceImpl) UpdateUser(ctx context.Context, user *models.UpdateReq, id, version uint) error{
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+	// Not cached: the version check must run on every call.
+	if err := s.repo.Update(ctx, user, id, version); err != nil {
+		return err
 	}
-	return updateResult
+	s.redisClient.Del(ctx, fmt.Sprintf("user:%d", id))
+	return nil
 }
 
-func (s *UserServiceImpl) DeleteUser(id uint) error{
+func (s *UserServiceImpl) DeleteUser(ctx context.Context, id uint) error{
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
 	cacheKey := fmt.Sprintf("delete_user:%d", id)
-	cachedData, err := s.redisClient.Get(s.context, cacheKey).Result()
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
 		if err == nil && cachedData != "" {
 			var result string
 			if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
@@ -235,7 +236,10 @@ func (s *UserServiceImpl) DeleteUser(id uint) error{
 				}
 			}
 	}
-	deleteResult := s.repo.Delete(id)
+	deleteResult := s.repo.Delete(ctx, id)
+	if deleteResult == nil {
+		s.redisClient.Del(ctx, fmt.Sprintf("user:%d", id))
+	}
 	var result string
 	if deleteResult != nil {
 		result = deleteResult.Error()
@@ -244,8 +248,41 @@ func (s *UserServiceImpl) DeleteUser(id uint) error{
 	}
 	data, err := json.Marshal(result)
 	if err == nil {
-		s.redisClient.Set(s.context, cacheKey, data, 5 * time.Minute)
-		log.Print("Cached delete user data")
+		s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+		slog.DebugContext(ctx, "Cached delete user data")
 	}
 	return deleteResult
-}
\ No newline at end of file
+}
+
+func (s *UserServiceImpl) SetAdmin(ctx context.Context, id uint, admin bool) error {
+	ctx, span := tracing.Start(ctx, "UserService.SetAdmin")
+	defer span.End()
+	if err := s.repo.SetAdmin(ctx, id, admin); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("user:%d", id))
+	return nil
+}
+
+// ResetPassword also drops the cached login, which would otherwise accept
+// the old password until it expires.
+func (s *UserServiceImpl) ResetPassword(ctx context.Context, id uint, pwd []byte) error {
+	ctx, span := tracing.Start(ctx, "UserService.ResetPassword")
+	defer span.End()
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword(pwd, 12)
+	if err != nil {
+		return err
+	}
+	for i := range pwd {
+		pwd[i] = 0
+	}
+	if err := s.repo.SetPassword(ctx, id, hash); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("user:%d", id), fmt.Sprintf("login_user:username=%s", user.Username))
+	return nil
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/Quavke/eBookReader/pkg/storage"
+	"github.com/Quavke/eBookReader/pkg/webhooks"
+)
+
+var (
+	ErrWebhookForbidden = errors.New("only admins may register global webhooks")
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http or https url on a public host")
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, ownerID uint, req *models.CreateWebhookReq) (*models.WebhookResp, error)
+	ListWebhooks(ctx context.Context, ownerID uint) ([]models.WebhookResp, error)
+	DeleteWebhook(ctx context.Context, id, ownerID uint) error
+	ListDeliveries(ctx context.Context, id, ownerID, limit, page uint) (*models.Pagination, error)
+	TestWebhook(ctx context.Context, id, ownerID uint) (*models.WebhookDeliveryResp, error)
+	Emit(ctx context.Context, key string, event string, book *models.Book)
+}
+
+type WebhookServiceImpl struct {
+	repo repositories.WebhookRepo
+	userRepo repositories.UserRepo
+	sender *webhooks.Sender
+	// context bounds deliveries, which retry long after the request that
+	// triggered them has finished. Cancelling it abandons pending retries.
+	context context.Context
+	// deliveries counts running deliveries, including those waiting for a
+	// retry, so shutdown can wait for them.
+	deliveries *sync.WaitGroup
+	store storage.BlobStore
+}
+
+func NewWebhookService(repo repositories.WebhookRepo, userRepo repositories.UserRepo, sender *webhooks.Sender, context context.Context, deliveries *sync.WaitGroup, store storage.BlobStore) *WebhookServiceImpl {
+	return &WebhookServiceImpl{
+		repo: repo,
+		userRepo: userRepo,
+		sender: sender,
+		context: context,
+		deliveries: deliveries,
+		store: store,
+	}
+}
+
+var _ WebhookService = (*WebhookServiceImpl)(nil)
+
+// CreateWebhook generates a secret when none is given. It is returned only
+// here, receivers have to store it.
+func (s *WebhookServiceImpl) CreateWebhook(ctx context.Context, ownerID uint, req *models.CreateWebhookReq) (*models.WebhookResp, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.CreateWebhook")
+	defer span.End()
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if err := webhooks.CheckHost(ctx, u.Hostname()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookURL, err)
+	}
+	if req.Global {
+		owner, err := s.userRepo.GetByID(ctx, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if !owner.IsAdmin {
+			return nil, ErrWebhookForbidden
+		}
+	}
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = webhooks.NewSecret(); err != nil {
+			return nil, err
+		}
+	}
+	hook := &models.Webhook{
+		OwnerID: ownerID,
+		URL: req.URL,
+		Secret: secret,
+		Events: req.Events,
+		Global: req.Global,
+		Active: true,
+	}
+	if err := s.repo.Create(ctx, hook); err != nil {
+		return nil, err
+	}
+	resp := webhookResp(*hook)
+	resp.Secret = secret
+	return &resp, nil
+}
+
+func (s *WebhookServiceImpl) ListWebhooks(ctx context.Context, ownerID uint) ([]models.WebhookResp, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListWebhooks")
+	defer span.End()
+	hooks, err := s.repo.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.WebhookResp, 0, len(hooks))
+	for _, h := range hooks {
+		resp = append(resp, webhookResp(h))
+	}
+	return resp, nil
+}
+
+func (s *WebhookServiceImpl) DeleteWebhook(ctx context.Context, id, ownerID uint) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+	return s.repo.Delete(ctx, id, ownerID)
+}
+
+func (s *WebhookServiceImpl) ListDeliveries(ctx context.Context, id, ownerID, limit, page uint) (*models.Pagination, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListDeliveries")
+	defer span.End()
+	if _, err := s.repo.GetByID(ctx, id, ownerID); err != nil {
+		return nil, err
+	}
+	p, err := s.repo.ListDeliveries(ctx, id, &models.Pagination{Limit: limit, Page: page, Sort: "id desc"})
+	if err != nil {
+		return nil, err
+	}
+	rows := p.Rows.([]models.WebhookDelivery)
+	deliveries := make([]models.WebhookDeliveryResp, 0, len(rows))
+	for _, d := range rows {
+		deliveries = append(deliveries, deliveryResp(d))
+	}
+	p.Rows = deliveries
+	return p, nil
+}
+
+// TestWebhook sends a ping once and synchronously, so the caller sees the
+// outcome right away. Pings are not retried.
+func (s *WebhookServiceImpl) TestWebhook(ctx context.Context, id, ownerID uint) (*models.WebhookDeliveryResp, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.TestWebhook")
+	defer span.End()
+	hook, err := s.repo.GetByID(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := newWebhookPayload(models.WebhookPing, map[string]uint{"webhook_id": hook.ID})
+	if err != nil {
+		return nil, err
+	}
+	delivery := s.attempt(*hook, payload, 1)
+	resp := deliveryResp(delivery)
+	return &resp, nil
+}
+
+// Emit fans event out to every subscribed webhook in the background; it never
+// fails the operation that triggered it. key identifies the triggering domain
+// event: a redelivered event reuses its delivery ids, so receivers can
+// deduplicate it.
+func (s *WebhookServiceImpl) Emit(ctx context.Context, key string, event string, book *models.Book) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Emit")
+	defer span.End()
+	hooks, err := s.repo.ListSubscribed(ctx, event, book.AuthorID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Webhook service Emit error, list webhooks", "error", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	var data any = bookListItem(*book, s.store)
+	if event == models.WebhookBookDeleted {
+		data = map[string]uint{"id": book.ID, "author_id": book.AuthorID}
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Webhook service Emit error, build payload", "error", err)
+		return
+	}
+	for _, h := range hooks {
+		payload := models.WebhookPayload{
+			ID: fmt.Sprintf("%s-%d", key, h.ID),
+			Event: event,
+			CreatedAt: time.Now().UTC(),
+			Data: raw,
+		}
+		s.deliveries.Add(1)
+		go s.deliver(h, payload)
+	}
+}
+
+// deliver retries with exponential backoff. Pending retries live in memory
+// and are lost on restart.
+func (s *WebhookServiceImpl) deliver(hook models.Webhook, payload models.WebhookPayload) {
+	defer s.deliveries.Done()
+	for attempt := uint(1); ; attempt++ {
+		delivery := s.attempt(hook, payload, attempt)
+		if delivery.Success {
+			return
+		}
+		if attempt >= webhooks.MaxAttempts {
+			slog.WarnContext(s.context, "Webhook service: giving up on delivery", "delivery_id", payload.ID, "webhook_id", hook.ID, "attempts", attempt)
+			return
+		}
+		retry := time.NewTimer(webhooks.Backoff(attempt))
+		select {
+		case <-retry.C:
+		case <-s.context.Done():
+			retry.Stop()
+			slog.WarnContext(s.context, "Webhook service: delivery abandoned on shutdown", "delivery_id", payload.ID, "webhook_id", hook.ID, "attempts", attempt)
+			return
+		}
+	}
+}
+
+func (s *WebhookServiceImpl) attempt(hook models.Webhook, payload models.WebhookPayload, attempt uint) models.WebhookDelivery {
+	started := time.Now()
+	status, err := s.sender.Send(s.context, hook.URL, hook.Secret, payload)
+	delivery := models.WebhookDelivery{
+		WebhookID: hook.ID,
+		DeliveryID: payload.ID,
+		Event: payload.Event,
+		Attempt: attempt,
+		StatusCode: status,
+		DurationMs: time.Since(started).Milliseconds(),
+		Success: err == nil,
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	// A send cut short by shutdown is still recorded.
+	if err := s.repo.LogDelivery(context.WithoutCancel(s.context), &delivery); err != nil {
+		slog.ErrorContext(s.context, "Webhook service error, log delivery", "error", err)
+	}
+	return delivery
+}
+
+func newWebhookPayload(event string, data any) (models.WebhookPayload, error) {
+	id, err := webhooks.NewDeliveryID()
+	if err != nil {
+		return models.WebhookPayload{}, err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return models.WebhookPayload{}, err
+	}
+	return models.WebhookPayload{ID: id, Event: event, CreatedAt: time.Now().UTC(), Data: raw}, nil
+}
+
+func webhookResp(h models.Webhook) models.WebhookResp {
+	return models.WebhookResp{
+		ID: h.ID,
+		URL: h.URL,
+		Events: h.Events,
+		Global: h.Global,
+		Active: h.Active,
+		CreatedAt: h.CreatedAt,
+	}
+}
+
+func deliveryResp(d models.WebhookDelivery) models.WebhookDeliveryResp {
+	return models.WebhookDeliveryResp{
+		DeliveryID: d.DeliveryID,
+		Event: d.Event,
+		Attempt: d.Attempt,
+		StatusCode: d.StatusCode,
+		Error: d.Error,
+		DurationMs: d.DurationMs,
+		Success: d.Success,
+		CreatedAt: d.CreatedAt,
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/repositories"
+	"github.com/Quavke/eBookReader/pkg/tracing"
+	"github.com/Quavke/eBookReader/pkg/storage"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/text/language"
+)
+
+var ErrInvalidLanguage = errors.New("language must be a BCP 47 tag such as en, pt-BR or zh-Hant")
+
+type WorkService interface {
+	GetWork(ctx context.Context, id uint, acceptLanguage string) (*models.WorkResp, error)
+	LinkTranslation(ctx context.Context, originalID, translationID, userID uint) (*models.WorkResp, error)
+	UnlinkTranslation(ctx context.Context, bookID, translationID, userID uint) error
+}
+
+type WorkServiceImpl struct {
+	repo repositories.WorkRepo
+	bookRepo repositories.BookRepo
+	redisClient *redis.Client
+	store storage.BlobStore
+}
+
+func NewWorkService(repo repositories.WorkRepo, bookRepo repositories.BookRepo, redisClient *redis.Client, store storage.BlobStore) *WorkServiceImpl {
+	return &WorkServiceImpl{
+		repo: repo,
+		bookRepo: bookRepo,
+		redisClient: redisClient,
+		store: store,
+	}
+}
+
+var _ WorkService = (*WorkServiceImpl)(nil)
+
+// workData is what gets cached per work; the edition is picked per request.
+type workData struct {
+	Work  models.WorkResp
+	Books []models.BookResp
+}
+
+// GetWork returns the work with all its editions and picks the edition that
+// best matches acceptLanguage, falling back to the original.
+func (s *WorkServiceImpl) GetWork(ctx context.Context, id uint, acceptLanguage string) (*models.WorkResp, error) {
+	ctx, span := tracing.Start(ctx, "WorkService.GetWork")
+	defer span.End()
+	data, err := s.getWorkData(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	work := data.Work
+	if i := MatchEdition(work.Editions, work.OriginalID, acceptLanguage); i >= 0 {
+		work.Edition = &data.Books[i]
+	}
+	return &work, nil
+}
+
+func (s *WorkServiceImpl) getWorkData(ctx context.Context, id uint) (*workData, error) {
+	cacheKey := fmt.Sprintf("work:%d", id)
+	cachedData, err := s.redisClient.Get(ctx, cacheKey).Result()
+	if err == nil && cachedData != "" {
+		var work workData
+		if err := json.Unmarshal([]byte(cachedData), &work); err == nil {
+			return &work, nil
+		}
+	}
+	workDB, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	work := &workData{
+		Work: models.WorkResp{
+			ID: workDB.ID,
+			Title: workDB.Title,
+			OriginalID: workDB.OriginalID,
+			Editions: make([]models.EditionResp, 0, len(workDB.Editions)),
+		},
+		Books: make([]models.BookResp, 0, len(workDB.Editions)),
+	}
+	for _, b := range workDB.Editions {
+		work.Work.Editions = append(work.Work.Editions, models.EditionResp{
+			BookID: b.ID,
+			Title: b.Title,
+			Language: b.Language,
+			Original: b.ID == workDB.OriginalID,
+		})
+		work.Books = append(work.Books, bookListItem(b, s.store))
+	}
+
+	data, err := json.Marshal(work)
+	if err == nil {
+		s.redisClient.Set(ctx, cacheKey, data, 5 * time.Minute)
+		slog.DebugContext(ctx, "Cached work data")
+	}
+	return work, nil
+}
+
+func (s *WorkServiceImpl) LinkTranslation(ctx context.Context, originalID, translationID, userID uint) (*models.WorkResp, error) {
+	ctx, span := tracing.Start(ctx, "WorkService.LinkTranslation")
+	defer span.End()
+	for _, bookID := range []uint{originalID, translationID} {
+		isBelongs, err := s.bookRepo.IsBelongsTo(ctx, bookID, userID)
+		if err != nil || !isBelongs {
+			return nil, ErrNotBookAuthor
+		}
+	}
+	// The translation may be moved out of another work, whose cached
+	// editions then go stale as well.
+	translation, err := s.bookRepo.GetByID(ctx, translationID)
+	if err != nil {
+		return nil, err
+	}
+	work, err := s.repo.LinkTranslation(ctx, originalID, translationID)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{
+		fmt.Sprintf("work:%d", work.ID),
+		fmt.Sprintf("book:%d", originalID),
+		fmt.Sprintf("book:%d", translationID),
+	}
+	if translation.WorkID != nil && *translation.WorkID != work.ID {
+		keys = append(keys, fmt.Sprintf("work:%d", *translation.WorkID))
+	}
+	s.redisClient.Del(ctx, keys...)
+	return s.GetWork(ctx, work.ID, "")
+}
+
+func (s *WorkServiceImpl) UnlinkTranslation(ctx context.Context, bookID, translationID, userID uint) error {
+	ctx, span := tracing.Start(ctx, "WorkService.UnlinkTranslation")
+	defer span.End()
+	isBelongs, err := s.bookRepo.IsBelongsTo(ctx, bookID, userID)
+	if err != nil || !isBelongs {
+		return ErrNotBookAuthor
+	}
+	book, err := s.bookRepo.GetByID(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UnlinkTranslation(ctx, bookID, translationID); err != nil {
+		return err
+	}
+	if book.WorkID != nil {
+		s.redisClient.Del(ctx, fmt.Sprintf("work:%d", *book.WorkID))
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf("book:%d", translationID))
+	return nil
+}
+
+// normalizeLanguage validates a BCP 47 tag and returns its canonical form;
+// an empty tag means the language is undetermined.
+func normalizeLanguage(tag string) (string, error) {
+	if tag == "" {
+		return language.Und.String(), nil
+	}
+	parsed, err := language.Parse(tag)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidLanguage, err)
+	}
+	return parsed.String(), nil
+}
+
+// MatchEdition returns the index of the edition that best serves the
+// Accept-Language header, or the original when nothing is close enough.
+func MatchEdition(editions []models.EditionResp, originalID uint, acceptLanguage string) int {
+	if len(editions) == 0 {
+		return -1
+	}
+	fallback := 0
+	tags := make([]language.Tag, 0, len(editions))
+	for i, e := range editions {
+		if e.BookID == originalID {
+			fallback = i
+		}
+		tags = append(tags, language.Make(e.Language))
+	}
+	desired, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(desired) == 0 {
+		return fallback
+	}
+	_, index, confidence := language.NewMatcher(tags).Match(desired...)
+	if confidence == language.No {
+		return fallback
+	}
+	return index
+}
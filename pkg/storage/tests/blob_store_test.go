@@ -0,0 +1,135 @@
+package storage_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 - минимальная замена S3/MinIO: хранит объекты в памяти и проверяет подпись запроса
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=test-key/") || !strings.Contains(auth, "Signature=") {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			http.Error(w, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store_RoundTrip(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "covers",
+		AccessKey: "test-key",
+		SecretKey: "test-secret",
+	}, server.Client())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	err = store.Put(ctx, "covers/1/original.png", strings.NewReader("png-bytes"), 9, "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", fake.types["/covers/covers/1/original.png"])
+
+	body, err := store.Get(ctx, "covers/1/original.png")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "png-bytes", string(data))
+	assert.Equal(t, server.URL+"/covers/covers/1/original.png", store.URL("covers/1/original.png"))
+
+	require.NoError(t, store.Delete(ctx, "covers/1/original.png"))
+	_, err = store.Get(ctx, "covers/1/original.png")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+}
+
+func TestS3Store_RejectedRequest(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	// Неверный ключ доступа - сервер отвечает 403, ошибка должна дойти до вызывающего
+	store, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "covers",
+		AccessKey: "wrong-key",
+		SecretKey: "test-secret",
+	}, server.Client())
+	require.NoError(t, err)
+
+	err = store.Put(context.Background(), "a.txt", strings.NewReader("x"), 1, "text/plain")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestLocalStore_RoundTrip(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir(), "/media/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "covers/2/small.jpg", strings.NewReader("jpeg"), 4, "image/jpeg"))
+
+	body, err := store.Get(ctx, "covers/2/small.jpg")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "jpeg", string(data))
+	assert.Equal(t, "/media/covers/2/small.jpg", store.URL("covers/2/small.jpg"))
+
+	require.NoError(t, store.Delete(ctx, "covers/2/small.jpg"))
+	_, err = store.Get(ctx, "covers/2/small.jpg")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+}
+
+func TestLocalStore_InvalidKeys(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir(), "/media")
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../etc/passwd", "/abs/path", "a/../../b", "a\\b"} {
+		err := store.Put(context.Background(), key, strings.NewReader("x"), 1, "")
+		assert.ErrorIs(t, err, storage.ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,56 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+
+	// Без экспортёра трассировка выключена, но shutdown безопасен
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestServiceSpansJoinIncomingTrace(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Config{})
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(otelgin.Middleware("test"))
+	router.GET("/books/:id", func(c *gin.Context) {
+		_, span := tracing.Start(c.Request.Context(), "BookService.GetBookByID")
+		span.End()
+	})
+
+	// Заголовок traceparent от клиента продолжает его трассу
+	req := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	service, server := spans[0], spans[1]
+	assert.Equal(t, "BookService.GetBookByID", service.Name())
+	assert.Equal(t, "/books/:id", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, server.SpanContext().SpanID(), service.Parent().SpanID())
+}
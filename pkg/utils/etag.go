@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrMissingIfMatch = errors.New("If-Match header is required")
+	ErrInvalidIfMatch = errors.New("If-Match header must contain a single entity tag")
+)
+
+func VersionETag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// CompositeETag prefixes the hash of v with version, so ParseIfMatch still
+// recovers the version from a representation that embeds other resources.
+func CompositeETag(version uint, v any) (string, error) {
+	hash, err := StrongETag(v)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%d-%s`, version, hash[1:]), nil
+}
+
+// StrongETag hashes the JSON representation of v, so any change of the payload changes the tag.
+func StrongETag(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// ParseIfMatch returns the version encoded in an entity tag produced by
+// VersionETag or CompositeETag.
+func ParseIfMatch(header string) (uint, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, ErrMissingIfMatch
+	}
+	if header == "*" || strings.Contains(header, ",") {
+		return 0, ErrInvalidIfMatch
+	}
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, ErrInvalidIfMatch
+	}
+	tag := header[1 : len(header)-1]
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+	version, err := strconv.ParseUint(tag, 10, 64)
+	if err != nil || version == 0 {
+		return 0, ErrInvalidIfMatch
+	}
+	return uint(version), nil
+}
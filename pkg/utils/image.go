@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("image must be JPEG, PNG or WebP")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+const maxImagePixels = 40_000_000
+
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// DetectImageType checks the magic bytes instead of trusting the client's
+// Content-Type and returns the MIME type and file extension.
+func DetectImageType(data []byte) (string, string, error) {
+	var mime string
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		mime = "image/jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		mime = "image/png"
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		mime = "image/webp"
+	default:
+		return "", "", ErrUnsupportedImage
+	}
+	return mime, imageExtensions[mime], nil
+}
+
+// DecodeImage refuses images whose header announces more than maxImagePixels
+// before allocating memory for them.
+func DecodeImage(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	return img, nil
+}
+
+// ThumbnailJPEG scales img down to width, keeping the aspect ratio. Smaller
+// images are never upscaled.
+func ThumbnailJPEG(img image.Image, width int) ([]byte, error) {
+	bounds := img.Bounds()
+	if width > bounds.Dx() {
+		width = bounds.Dx()
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height == 0 {
+		height = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	// JPEG has no alpha channel, so transparent covers get a white background.
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
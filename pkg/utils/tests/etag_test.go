@@ -0,0 +1,64 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/Quavke/eBookReader/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version uint
+		err     error
+	}{
+		{name: "version tag", header: `"7"`, version: 7},
+		{name: "surrounding spaces", header: `  "12"  `, version: 12},
+		{name: "composite tag", header: `"3-0a1b2c"`, version: 3},
+		{name: "missing", header: "", err: utils.ErrMissingIfMatch},
+		{name: "blank", header: "   ", err: utils.ErrMissingIfMatch},
+		{name: "wildcard", header: "*", err: utils.ErrInvalidIfMatch},
+		{name: "list", header: `"1", "2"`, err: utils.ErrInvalidIfMatch},
+		{name: "unquoted", header: "7", err: utils.ErrInvalidIfMatch},
+		{name: "weak", header: `W/"7"`, err: utils.ErrInvalidIfMatch},
+		{name: "zero", header: `"0"`, err: utils.ErrInvalidIfMatch},
+		{name: "not a number", header: `"abc"`, err: utils.ErrInvalidIfMatch},
+		{name: "single quote", header: `"`, err: utils.ErrInvalidIfMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := utils.ParseIfMatch(tt.header)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestCompositeETag(t *testing.T) {
+	tag, err := utils.CompositeETag(5, map[string]string{"title": "Emma"})
+	require.NoError(t, err)
+
+	// Версия извлекается обратно из составного тега
+	version, err := utils.ParseIfMatch(tag)
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), version)
+
+	// Другое содержимое при той же версии даёт другой тег
+	other, err := utils.CompositeETag(5, map[string]string{"title": "Persuasion"})
+	require.NoError(t, err)
+	assert.NotEqual(t, tag, other)
+
+	same, err := utils.CompositeETag(5, map[string]string{"title": "Emma"})
+	require.NoError(t, err)
+	assert.Equal(t, tag, same)
+
+	assert.Equal(t, `"9"`, utils.VersionETag(9))
+}
@@ -0,0 +1,70 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrForbiddenAddress is returned for webhook hosts that resolve to the
+// server's own network: loopback, link-local (cloud metadata), private,
+// shared and unspecified addresses.
+var ErrForbiddenAddress = errors.New("webhook host must resolve to a public address")
+
+// reserved holds ranges that IsGlobalUnicast lets through but that are not
+// reachable on the public internet, or that translate into ones that are not.
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// PublicAddr reports whether webhooks may be delivered to ip.
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range reserved {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host and fails unless every address it has is public,
+// so a name with one internal record cannot be registered. It runs when a
+// webhook is created; the dialer repeats the check on every connection
+// because the records may change afterwards.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !PublicAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// dialControl runs after name resolution, on the address actually dialed,
+// which keeps DNS rebinding and redirects from reaching internal hosts.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !PublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr().Unmap())
+	}
+	return nil
+}
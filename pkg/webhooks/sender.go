@@ -0,0 +1,121 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+)
+
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+
+	MaxAttempts = 6
+	baseBackoff = 5 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Sign returns the value of SignatureHeader. The timestamp is signed with the
+// body so a captured request cannot be replayed later with a fresh timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Backoff is the delay before attempt+1: 5s, 10s, 20s... capped at an hour.
+func Backoff(attempt uint) time.Duration {
+	d := baseBackoff
+	for i := uint(1); i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+func NewSecret() (string, error) {
+	return randomHex(24)
+}
+
+func NewDeliveryID() (string, error) {
+	return randomHex(16)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender uses client as is. Without one it builds a client that only
+// connects to public addresses and ignores proxy settings, which would
+// otherwise dial on its behalf.
+func NewSender(client *http.Client) *Sender {
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   dialControl,
+		}).DialContext
+		client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	}
+	return &Sender{client: client, now: time.Now}
+}
+
+// Send makes a single delivery attempt. A non-2xx answer is returned as an
+// error together with its status code.
+func (s *Sender) Send(ctx context.Context, url, secret string, payload models.WebhookPayload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "eBookReader-Webhooks/1.0")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, ts, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,120 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Quavke/eBookReader/pkg/models"
+	"github.com/Quavke/eBookReader/pkg/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_Send(t *testing.T) {
+	const secret = "0123456789abcdef"
+	var verified bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(webhooks.TimestampHeader), 10, 64)
+		verified = webhooks.Verify(secret, ts, body, r.Header.Get(webhooks.SignatureHeader))
+		assert.Equal(t, models.WebhookBookCreated, r.Header.Get(webhooks.EventHeader))
+		assert.Equal(t, "d1", r.Header.Get(webhooks.DeliveryHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := webhooks.NewSender(server.Client())
+	payload := models.WebhookPayload{ID: "d1", Event: models.WebhookBookCreated, CreatedAt: time.Now(), Data: json.RawMessage(`{"id":1}`)}
+
+	status, err := sender.Send(context.Background(), server.URL, secret, payload)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.True(t, verified)
+}
+
+func TestSender_SendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	status, err := webhooks.NewSender(server.Client()).Send(context.Background(), server.URL, "secret", models.WebhookPayload{Data: json.RawMessage(`{}`)})
+
+	// Ответ не 2xx считается неудачной доставкой
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadGateway, status)
+}
+
+func TestVerify_RejectsTampering(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	sig := webhooks.Sign("secret", 100, body)
+
+	assert.True(t, webhooks.Verify("secret", 100, body, sig))
+	assert.False(t, webhooks.Verify("secret", 101, body, sig))
+	assert.False(t, webhooks.Verify("other", 100, body, sig))
+	assert.False(t, webhooks.Verify("secret", 100, []byte(`{"id":2}`), sig))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Second, webhooks.Backoff(1))
+	assert.Equal(t, 10*time.Second, webhooks.Backoff(2))
+	assert.Equal(t, 40*time.Second, webhooks.Backoff(4))
+	assert.Equal(t, time.Hour, webhooks.Backoff(30))
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":            true,
+		"2606:4700::1111":    true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"10.1.2.3":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"fd00::1":            false,
+		"100.64.0.1":         false,
+		"0.0.0.0":            false,
+		"::":                 false,
+		"::ffff:127.0.0.1":   false,
+		"::ffff:10.0.0.1":    false,
+		"224.0.0.1":          false,
+		"64:ff9b::a9fe:a9fe": false,
+	}
+	for addr, public := range tests {
+		assert.Equal(t, public, webhooks.PublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	assert.NoError(t, webhooks.CheckHost(context.Background(), "8.8.8.8"))
+
+	// Внутренние адреса запрещены и литералами, и через имена
+	for _, host := range []string{"127.0.0.1", "169.254.169.254", "10.0.0.1", "::1", "localhost"} {
+		assert.ErrorIs(t, webhooks.CheckHost(context.Background(), host), webhooks.ErrForbiddenAddress, host)
+	}
+}
+
+func TestSender_RefusesInternalAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// Клиент по умолчанию проверяет адрес при подключении, а не только при создании
+	_, err := webhooks.NewSender(nil).Send(context.Background(), server.URL, "secret", models.WebhookPayload{Data: json.RawMessage(`{}`)})
+
+	assert.ErrorIs(t, err, webhooks.ErrForbiddenAddress)
+	assert.False(t, called)
+}